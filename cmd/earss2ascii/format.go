@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ozym/earss/internal/earss"
+)
+
+// TimeFormat is used for sample times, it matches the IRIS ASCII formats.
+const TimeFormat = "2006-01-02T15:04:05.000000"
+
+// Format writes decoded EARSS records as text.
+type Format interface {
+	Record(earss.Record) error
+	Flush() error
+}
+
+// sampleTime returns the time of a sample offset from a start time.
+func sampleTime(start time.Time, period time.Duration, offset int) time.Time {
+	return start.Add(time.Duration(offset) * period)
+}
+
+// Header writes a single summary line for each record.
+type Header struct {
+	wr io.Writer
+}
+
+// NewHeader returns a Header that writes to the given io.Writer.
+func NewHeader(wr io.Writer) *Header {
+	return &Header{wr: wr}
+}
+
+// Record writes the record header line.
+func (h *Header) Record(record earss.Record) error {
+	_, err := fmt.Fprintln(h.wr, record.Header())
+	return err
+}
+
+// Flush is a no-op as each line is written as the record is given.
+func (h *Header) Flush() error {
+	return nil
+}
+
+// Raw writes each record header followed by all of its sample values.
+type Raw struct {
+	wr io.Writer
+}
+
+// NewRaw returns a Raw that writes to the given io.Writer.
+func NewRaw(wr io.Writer) *Raw {
+	return &Raw{wr: wr}
+}
+
+// Record writes the record header and sample values.
+func (r *Raw) Record(record earss.Record) error {
+	_, err := fmt.Fprint(r.wr, record.String())
+	return err
+}
+
+// Flush is a no-op as the samples are written as the record is given.
+func (r *Raw) Flush() error {
+	return nil
+}
+
+// TimeSeries writes IRIS ASCII TSPAIR or SLIST blocks for each continuous trace.
+type TimeSeries struct {
+	wr       io.Writer
+	settings Settings
+	slist    bool
+	asm      *earss.Assembler
+}
+
+// NewTimeSeries returns a TimeSeries that writes to the given io.Writer, using the settings for the stream
+// codes, SLIST blocks are written if slist is set otherwise TSPAIR blocks are used.
+func NewTimeSeries(wr io.Writer, settings Settings, slist bool) *TimeSeries {
+	ts := TimeSeries{
+		wr:       wr,
+		settings: settings,
+		slist:    slist,
+	}
+	ts.asm = earss.NewAssembler(0, ts.trace)
+	ts.asm.SetMaxSamples(settings.maxsamples)
+	ts.asm.SetDaily(settings.daily)
+	// the samples are not calibrated, so gain changes do not need a new block.
+	ts.asm.SetSplitGains(false)
+	return &ts
+}
+
+// Record adds the record to the continuous traces, a block is written for any trace that has ended.
+func (t *TimeSeries) Record(record earss.Record) error {
+	return t.asm.Add(record)
+}
+
+// Flush writes a block for each of the outstanding traces.
+func (t *TimeSeries) Flush() error {
+	return t.asm.Flush()
+}
+
+// trace writes a single block, it is used as the Assembler callback.
+func (t *TimeSeries) trace(trace earss.Trace) error {
+	src := strings.Join([]string{t.settings.network, t.settings.Station(trace.Instrument), t.settings.location, t.settings.Channel(trace.Channel), "D"}, "_")
+
+	format := "TSPAIR"
+	if t.slist {
+		format = "SLIST"
+	}
+
+	if _, err := fmt.Fprintf(t.wr, "TIMESERIES %s, %d samples, %d sps, %s, %s, INTEGER, COUNTS\n",
+		src, len(trace.Samples), trace.SampleRate, trace.Start.Format(TimeFormat), format); err != nil {
+		return err
+	}
+
+	switch {
+	case t.slist:
+		for i := 0; i < len(trace.Samples); i += 6 {
+			var parts []string
+			for j := i; j < i+6 && j < len(trace.Samples); j++ {
+				parts = append(parts, fmt.Sprintf("%10d", trace.Samples[j]))
+			}
+			if _, err := fmt.Fprintln(t.wr, strings.Join(parts, "  ")); err != nil {
+				return err
+			}
+		}
+	default:
+		for i, v := range trace.Samples {
+			at := sampleTime(trace.Start, trace.SamplePeriod(), i)
+			if _, err := fmt.Fprintf(t.wr, "%s  %d\n", at.Format(TimeFormat), v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// CSV writes a row for each sample time, with a column for each channel.
+type CSV struct {
+	wr       *csv.Writer
+	settings Settings
+	header   bool
+}
+
+// NewCSV returns a CSV that writes to the given io.Writer, using the settings for the channel column names.
+func NewCSV(wr io.Writer, settings Settings) *CSV {
+	return &CSV{
+		wr:       csv.NewWriter(wr),
+		settings: settings,
+	}
+}
+
+// Record writes a row for each sample time in the record, the column names are written before the first row.
+func (c *CSV) Record(record earss.Record) error {
+	if !c.header {
+		cols := []string{"time", "instrument", "buffer"}
+		for i := 0; i < earss.MaxChannels; i++ {
+			cols = append(cols, c.settings.Channel(i))
+		}
+		if err := c.wr.Write(cols); err != nil {
+			return err
+		}
+		c.header = true
+	}
+
+	start, period := record.Start(), record.SamplePeriod()
+	for i := 0; i < record.SampleCount(); i++ {
+		row := []string{
+			sampleTime(start, period, i).Format(TimeFormat),
+			strconv.Itoa(record.Instrument),
+			strconv.Itoa(record.BufferNumber),
+		}
+		for j := 0; j < earss.MaxChannels; j++ {
+			switch {
+			case j < record.NumberOfChannels:
+				row = append(row, strconv.Itoa(record.Samples[i*record.NumberOfChannels+j]))
+			default:
+				row = append(row, "")
+			}
+		}
+		if err := c.wr.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return c.wr.Error()
+}
+
+// Flush writes any buffered rows.
+func (c *CSV) Flush() error {
+	c.wr.Flush()
+	return c.wr.Error()
+}
+
+// Sample is used to encode the JSON lines output.
+type Sample struct {
+	Time       string   `json:"time"`
+	Instrument int      `json:"instrument"`
+	Buffer     int      `json:"buffer"`
+	Channels   []string `json:"channels"`
+	Values     []int    `json:"values"`
+}
+
+// JSON writes a JSON object for each sample time.
+type JSON struct {
+	enc      *json.Encoder
+	settings Settings
+}
+
+// NewJSON returns a JSON that writes to the given io.Writer, using the settings for the channel names.
+func NewJSON(wr io.Writer, settings Settings) *JSON {
+	return &JSON{
+		enc:      json.NewEncoder(wr),
+		settings: settings,
+	}
+}
+
+// Record writes a line for each sample time in the record.
+func (j *JSON) Record(record earss.Record) error {
+	var channels []string
+	for i := 0; i < record.NumberOfChannels; i++ {
+		channels = append(channels, j.settings.Channel(i))
+	}
+
+	start, period := record.Start(), record.SamplePeriod()
+	for i := 0; i < record.SampleCount(); i++ {
+		n := i * record.NumberOfChannels
+		if err := j.enc.Encode(Sample{
+			Time:       sampleTime(start, period, i).Format(TimeFormat),
+			Instrument: record.Instrument,
+			Buffer:     record.BufferNumber,
+			Channels:   channels,
+			Values:     record.Samples[n : n+record.NumberOfChannels],
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Flush is a no-op as each line is written as the record is given.
+func (j *JSON) Flush() error {
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ozym/earss/internal/earss"
+)
+
+func TestFormat_Output(t *testing.T) {
+
+	data, err := os.ReadFile("../../internal/earss/testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := earss.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := Settings{
+		network:    "NZ",
+		station:    "LYLM",
+		location:   "10",
+		channel:    "EH",
+		components: "ZNE",
+	}
+
+	formats := map[string]struct {
+		format func(*bytes.Buffer) Format
+		lines  int
+		first  []string
+		last   string
+	}{
+		"header": {
+			format: func(wr *bytes.Buffer) Format { return NewHeader(wr) },
+			lines:  3,
+			first: []string{
+				"1994-03-12T23:10:08.65Z  106   6 10 3 8   1 F 100  54 3 3 3",
+				"1994-03-12T23:10:25.93Z  106   6  0 3 8   2 F 100  54 3 3 3",
+			},
+			last: "1994-03-12T23:10:53.21Z  106   6  0 3 8   3 F 100  54 3 3 3",
+		},
+		"raw": {
+			format: func(wr *bytes.Buffer) Format { return NewRaw(wr) },
+			lines:  8187,
+			first: []string{
+				"1994-03-12T23:10:08.65Z 106 6 10 3 8 1 false 100 54 3 3 3",
+				" -3 3 2",
+			},
+			last: " 2 0 -19",
+		},
+		"tspair": {
+			format: func(wr *bytes.Buffer) Format { return NewTimeSeries(wr, settings, false) },
+			lines:  24555,
+			first: []string{
+				"TIMESERIES NZ_LYLM_10_EHZ_D, 8184 samples, 100 sps, 1994-03-12T23:09:58.650000, TSPAIR, INTEGER, COUNTS",
+				"1994-03-12T23:09:58.650000  -3",
+			},
+			last: "1994-03-12T23:11:20.480000  -19",
+		},
+		"slist": {
+			format: func(wr *bytes.Buffer) Format { return NewTimeSeries(wr, settings, true) },
+			lines:  4095,
+			first: []string{
+				"TIMESERIES NZ_LYLM_10_EHZ_D, 8184 samples, 100 sps, 1994-03-12T23:09:58.650000, SLIST, INTEGER, COUNTS",
+				"        -3          -4          -5          -4          -8          -7",
+			},
+			last: "       -17         -20         -20         -20         -20         -19",
+		},
+		"csv": {
+			format: func(wr *bytes.Buffer) Format { return NewCSV(wr, settings) },
+			lines:  8185,
+			first: []string{
+				"time,instrument,buffer,EHZ,EHN,EHE",
+				"1994-03-12T23:09:58.650000,106,1,-3,3,2",
+			},
+			last: "1994-03-12T23:11:20.480000,106,3,2,0,-19",
+		},
+		"json": {
+			format: func(wr *bytes.Buffer) Format { return NewJSON(wr, settings) },
+			lines:  8184,
+			first: []string{
+				`{"time":"1994-03-12T23:09:58.650000","instrument":106,"buffer":1,"channels":["EHZ","EHN","EHE"],"values":[-3,3,2]}`,
+				`{"time":"1994-03-12T23:09:58.660000","instrument":106,"buffer":1,"channels":["EHZ","EHN","EHE"],"values":[-4,6,1]}`,
+			},
+			last: `{"time":"1994-03-12T23:11:20.480000","instrument":106,"buffer":3,"channels":["EHZ","EHN","EHE"],"values":[2,0,-19]}`,
+		},
+	}
+
+	for k, v := range formats {
+		t.Run("format output: "+k, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			format := v.format(&buf)
+			for _, r := range records {
+				if err := format.Record(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := format.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if len(lines) != v.lines {
+				t.Fatalf("invalid number of lines, expected %d, got %d", v.lines, len(lines))
+			}
+			for i, l := range v.first {
+				if lines[i] != l {
+					t.Errorf("invalid line %d, expected %q, got %q", i+1, l, lines[i])
+				}
+			}
+			if l := lines[len(lines)-1]; l != v.last {
+				t.Errorf("invalid last line, expected %q, got %q", v.last, l)
+			}
+		})
+	}
+}
+
+func TestFormat_TimeSeriesLimits(t *testing.T) {
+
+	data, err := os.ReadFile("../../internal/earss/testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := earss.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no station code is given so the instrument number is used, and the traces are limited in length.
+	settings := Settings{
+		network:    "NZ",
+		location:   "10",
+		channel:    "EH",
+		components: "ZNE",
+		maxsamples: 5000,
+		daily:      true,
+	}
+
+	var buf bytes.Buffer
+
+	format := NewTimeSeries(&buf, settings, true)
+	for _, r := range records {
+		if err := format.Record(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := format.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var blocks, samples int
+	for _, l := range strings.Split(buf.String(), "\n") {
+		if !strings.HasPrefix(l, "TIMESERIES ") {
+			continue
+		}
+		if !strings.HasPrefix(l, "TIMESERIES NZ_106_10_EH") {
+			t.Errorf("invalid source name: %q", l)
+		}
+		var n int
+		if _, err := fmt.Sscanf(strings.SplitN(l, ", ", 2)[1], "%d samples", &n); err != nil {
+			t.Fatal(err)
+		}
+		if n > settings.maxsamples {
+			t.Errorf("invalid block length, expected no more than %d but got %d", settings.maxsamples, n)
+		}
+		blocks++
+		samples += n
+	}
+
+	if blocks <= 3 {
+		t.Errorf("invalid number of blocks, expected more than %d but got %d", 3, blocks)
+	}
+	if samples != 3*8184 {
+		t.Errorf("invalid number of samples, expected %d but got %d", 3*8184, samples)
+	}
+}
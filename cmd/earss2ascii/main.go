@@ -1,18 +1,23 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
-	"math"
 	"os"
-	//	"time"
+	"strconv"
+
 	"github.com/ozym/earss/internal/earss"
 )
 
 type Settings struct {
 	verbose bool
-	blksize int
+	format  string
+
+	maxsamples int
+	daily      bool
 
 	station    string
 	network    string
@@ -21,8 +26,12 @@ type Settings struct {
 	components string
 }
 
-func (s Settings) Blksize() int {
-	return int(math.Log(float64(s.blksize)) / math.Log(2))
+// Station returns the station code, the instrument number is used if no code has been given.
+func (s Settings) Station(instrument int) string {
+	if s.station != "" {
+		return s.station
+	}
+	return strconv.Itoa(instrument)
 }
 
 func (s Settings) Channel(offset int) string {
@@ -38,12 +47,21 @@ func main() {
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "Display EARSS header information\n")
+		fmt.Fprintf(os.Stderr, "Display EARSS header information and sample data\n")
 		fmt.Fprintf(os.Stderr, "\n")
 		fmt.Fprintf(os.Stderr, "Usage:\n")
 		fmt.Fprintf(os.Stderr, "\n")
 		fmt.Fprintf(os.Stderr, "  %s [options] <files...>\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Formats:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "  header  one line summary of each buffer header\n")
+		fmt.Fprintf(os.Stderr, "  raw     buffer header followed by the raw sample values\n")
+		fmt.Fprintf(os.Stderr, "  tspair  IRIS ASCII time and sample pairs for each continuous trace\n")
+		fmt.Fprintf(os.Stderr, "  slist   IRIS ASCII sample lists for each continuous trace\n")
+		fmt.Fprintf(os.Stderr, "  csv     comma separated sample time and channel values\n")
+		fmt.Fprintf(os.Stderr, "  json    JSON lines of sample time and channel values\n")
+		fmt.Fprintf(os.Stderr, "\n")
 		fmt.Fprintf(os.Stderr, "General Options:\n")
 		fmt.Fprintf(os.Stderr, "\n")
 		flag.PrintDefaults()
@@ -51,67 +69,90 @@ func main() {
 	}
 
 	flag.BoolVar(&settings.verbose, "verbose", false, "make noise.")
-	/*
-		flag.StringVar(&settings.network, "network", "XX", "miniseed network code")
-		flag.StringVar(&settings.station, "station", "XXXX", "miniseed station code")
-		flag.StringVar(&settings.location, "location", "XX", "miniseed location code prefix")
-		flag.StringVar(&settings.channel, "channel", "EH", "miniseed channel code prefix")
-		flag.StringVar(&settings.components, "components", "ZNE", "miniseed channel code suffix")
-		flag.IntVar(&settings.blksize, "blksize", 512, "miniseed block size in bytes")
-	*/
+	flag.StringVar(&settings.format, "format", "header", "output format, one of header, raw, tspair, slist, csv or json")
+	flag.StringVar(&settings.network, "network", "XX", "miniseed network code")
+	flag.StringVar(&settings.station, "station", "", "miniseed station code, the instrument number is used if not given")
+	flag.StringVar(&settings.location, "location", "XX", "miniseed location code prefix")
+	flag.StringVar(&settings.channel, "channel", "EH", "miniseed channel code prefix")
+	flag.StringVar(&settings.components, "components", "ZNE", "miniseed channel code suffix")
+	flag.IntVar(&settings.maxsamples, "maxsamples", 0, "maximum number of samples held for each channel before writing a trace, zero for no limit")
+	flag.BoolVar(&settings.daily, "daily", true, "write the samples held for each channel at the start of each UTC day")
 
 	flag.Parse()
 
+	if settings.maxsamples < 0 {
+		log.Fatalf("invalid maximum number of samples %d", settings.maxsamples)
+	}
+
+	wr := bufio.NewWriter(os.Stdout)
+	defer wr.Flush()
+
+	// any output written so far is flushed before exiting, as the deferred flush is skipped.
+	fatalf := func(format string, v ...interface{}) {
+		if err := wr.Flush(); err != nil {
+			log.Print(err)
+		}
+		log.Fatalf(format, v...)
+	}
+
+	var format Format
+	switch settings.format {
+	case "header":
+		format = NewHeader(wr)
+	case "raw":
+		format = NewRaw(wr)
+	case "tspair":
+		format = NewTimeSeries(wr, settings, false)
+	case "slist":
+		format = NewTimeSeries(wr, settings, true)
+	case "csv":
+		format = NewCSV(wr, settings)
+	case "json":
+		format = NewJSON(wr, settings)
+	default:
+		log.Fatalf("unknown format %q, expected header, raw, tspair, slist, csv or json", settings.format)
+	}
+
 	for _, f := range flag.Args() {
 		if settings.verbose {
 			log.Printf("displaying file %s", f)
 		}
-		data, err := os.ReadFile(f)
+		file, err := os.Open(f)
 		if err != nil {
-			log.Fatal(err)
+			fatalf("unable to open %s: %v", f, err)
 		}
 
-		records, err := earss.Decode(data)
-		if err != nil {
-			log.Fatal(err)
+		rd := earss.NewReader(file)
+		for {
+			if !rd.Next() {
+				err := rd.Err()
+				if err == nil {
+					break
+				}
+				var herr *earss.HeaderError
+				if !errors.As(err, &herr) {
+					fatalf("unable to read %s: %v", f, err)
+				}
+				log.Printf("skipping invalid buffer in %s: %v", f, err)
+				continue
+			}
+			if err := format.Record(rd.Record()); err != nil {
+				fatalf("unable to format %s: %v", f, err)
+			}
 		}
+		file.Close()
 
-		for _, record := range records {
-			fmt.Println(record.Header())
+		if settings.verbose {
+			log.Printf("read %d records from %s", rd.Index(), f)
 		}
+	}
 
-		/*
-			if settings.verbose {
-				log.Printf("read %d records from %s", len(records), f)
-			}
+	if err := format.Flush(); err != nil {
+		fatalf("unable to format output: %v", err)
+	}
 
-			var counter int
-			for _, record := range records {
-				for channel := 0; channel < record.NumberOfChannels; channel++ {
-					var samples []int32
-					for i := 0; i < earss.DataValues; i += record.NumberOfChannels {
-						samples = append(samples, int32(record.Samples[i+channel]))
-					}
-					rec := ms.NewEmptyRecord(settings.Blksize(), record.SampleRate, 1)
-					start := record.StartTime.Add(-time.Second * time.Duration(record.PreEventSeconds))
-					copy(rec.NetworkIdentifier[:], []byte(settings.network))
-					copy(rec.StationIdentifier[:], []byte(settings.station))
-					copy(rec.LocationIdentifier[:], []byte(settings.location))
-					copy(rec.ChannelIdentifier[:], []byte(settings.Channel(channel)))
-					rec.TimeCorrection = int32(100 * record.TimeCorrection)
-					if err := rec.PackSteim2(start, 0, samples, func(msr *ms.Record) error {
-						copy(msr.SequenceNumber[:], []byte(fmt.Sprintf("%06d", counter+1)))
-						counter++
-						return msr.Encode(os.Stdout)
-					}); err != nil {
-						log.Fatal(err)
-					}
-				}
-			}
-			if settings.verbose {
-				log.Printf("packed %d blocks from %s", counter, f)
-			}
-		*/
+	if err := wr.Flush(); err != nil {
+		log.Fatalf("unable to write output: %v", err)
 	}
 
 	if settings.verbose {
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ozym/earss/internal/earss"
+	"github.com/ozym/earss/internal/ms"
+)
+
+// Converter packs assembled EARSS traces into miniseed records.
+type Converter struct {
+	settings Settings
+	output   Output
+
+	// the last sample of each channel, used to continue steim differences.
+	last map[string]int32
+	// instruments that could not be mapped, to avoid repeated warnings.
+	unmapped map[int]bool
+	// mapped streams that have been reported, to avoid repeated notices.
+	mapped map[string]bool
+
+	counter int
+}
+
+// NewConverter returns a Converter that writes packed records to the given Output.
+func NewConverter(settings Settings, output Output) *Converter {
+	return &Converter{
+		settings: settings,
+		output:   output,
+		last:     make(map[string]int32),
+		unmapped: make(map[int]bool),
+		mapped:   make(map[string]bool),
+	}
+}
+
+// Count returns the number of miniseed records written.
+func (c *Converter) Count() int {
+	return c.counter
+}
+
+// Trace converts a single EARSS trace, it is suitable for use as an Assembler callback.
+func (c *Converter) Trace(trace earss.Trace) error {
+	// the measured rate is used for the record start times and is stored in a blockette 100, the nominal
+	// rate is kept if the trace is too short for a reliable measurement.
+	var measured float64
+	if c.settings.measurerate {
+		if rate, ok := trace.MeasuredRate(c.settings.measurespan); ok {
+			measured = rate
+		}
+	}
+
+	// a trace that crosses a station mapping window is converted in parts so each uses its own codes.
+	if parts := trace.SplitAt(measured, c.settings.mapping.Boundaries(trace.Instrument, trace.Start, trace.EndTime())...); len(parts) > 1 {
+		for _, p := range parts {
+			if err := c.Trace(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	network, station, location, channel, ok := c.settings.Codes(trace.Instrument, trace.Channel, trace.Start)
+	if !ok && c.settings.mapping != nil && !c.unmapped[trace.Instrument] {
+		log.Printf("no station mapping for instrument %d channel %d at %s, using defaults",
+			trace.Instrument, trace.Channel, trace.Start.Format(time.RFC3339))
+		c.unmapped[trace.Instrument] = true
+	}
+	if ok && c.settings.verbose {
+		c.report(trace)
+	}
+
+	rec := ms.NewEmptyRecord(c.settings.Blksize(), trace.SampleRate, 1)
+	rec.SetNetwork(network)
+	rec.SetStation(station)
+	rec.SetLocation(location)
+	rec.SetChannel(channel)
+	rec.B1001.TimingQuality = uint8(c.settings.TimingQuality(trace))
+
+	if measured > 0 {
+		rec.SetActualSampleRate(measured)
+	}
+
+	// ignore drops the correction, record stores it in the header for later use, whereas apply
+	// adjusts the start time and flags the correction as having been applied.
+	var pending time.Duration
+	switch correction := trace.Correction(); c.settings.timecorrection {
+	case "ignore":
+	case "apply":
+		if correction != 0 {
+			trace.Start = trace.Start.Add(correction)
+			rec.SetCorrection(correction, true)
+		}
+	default:
+		rec.SetCorrection(correction, false)
+		pending = correction
+	}
+
+	// output files are named using the corrected record start time, so days are split on the same basis.
+	traces := []earss.Trace{trace}
+	if c.settings.output != "" {
+		corrected := trace
+		corrected.Start = trace.Start.Add(pending)
+		traces = corrected.SplitDays(rec.ActualSampleRate())
+	}
+
+	var offset int
+	for _, t := range traces {
+		// keep the start times consistent with any actual sample rate
+		t.Start = trace.Start.Add(rec.SampleOffset(offset))
+		offset += len(t.Samples)
+
+		if err := c.pack(rec, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// report logs the codes and orientation of a mapped stream the first time it is used in each mapping window.
+func (c *Converter) report(trace earss.Trace) {
+	station, ok := c.settings.mapping.Lookup(trace.Instrument, trace.Start)
+	if !ok {
+		return
+	}
+	component, ok := station.Component(trace.Channel)
+	if !ok {
+		return
+	}
+
+	key := fmt.Sprintf("%d.%d.%s", trace.Instrument, trace.Channel, station.Start.Format(time.RFC3339Nano))
+	if c.mapped[key] {
+		return
+	}
+	c.mapped[key] = true
+
+	log.Printf("instrument %d channel %d mapped to %s.%s.%s.%s azimuth %g dip %g",
+		trace.Instrument, trace.Channel, station.Network, station.Station, station.Location, component.Channel,
+		component.Azimuth, component.Dip)
+}
+
+func (c *Converter) pack(rec *ms.Record, trace earss.Trace) error {
+
+	encode := func(msr *ms.Record) error {
+		copy(msr.SequenceNumber[:], []byte(fmt.Sprintf("%06d", c.counter+1)))
+		c.counter++
+		return c.output.Write(msr)
+	}
+
+	switch c.settings.encoding {
+	case "float32", "float64":
+		samples := trace.Calibrated()
+		if !c.settings.calibrate {
+			samples = make([]float64, 0, len(trace.Samples))
+			for _, v := range trace.Samples {
+				samples = append(samples, float64(v))
+			}
+		}
+		if c.settings.encoding == "float64" {
+			return rec.PackFloat64(trace.Start, samples, encode)
+		}
+		values := make([]float32, 0, len(samples))
+		for _, v := range samples {
+			values = append(values, float32(v))
+		}
+		return rec.PackFloat32(trace.Start, values, encode)
+	}
+
+	src := rec.SrcName(false)
+
+	var err error
+	switch c.settings.encoding {
+	case "steim1":
+		err = rec.PackSteim1(trace.Start, c.last[src], trace.Samples, encode)
+	case "steim2":
+		err = rec.PackSteim2(trace.Start, c.last[src], trace.Samples, encode)
+	default:
+		err = rec.PackInt32(trace.Start, trace.Samples, encode)
+	}
+	if n := len(trace.Samples); n > 0 {
+		c.last[src] = trace.Samples[n-1]
+	}
+
+	return err
+}
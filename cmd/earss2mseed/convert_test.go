@@ -0,0 +1,178 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ozym/earss/internal/earss"
+	"github.com/ozym/earss/internal/ms"
+)
+
+// records is an Output that keeps the written records.
+type records []ms.Record
+
+func (r *records) Write(msr *ms.Record) error {
+	*r = append(*r, *msr)
+	return nil
+}
+
+func (r *records) Close() error {
+	return nil
+}
+
+func TestConvert_TimeCorrection(t *testing.T) {
+	start := time.Date(1994, 3, 12, 23, 9, 58, 650000000, time.UTC)
+
+	trace := earss.Trace{
+		Instrument:     106,
+		SampleRate:     100,
+		Start:          start,
+		Samples:        make([]int32, 100),
+		TimeCorrection: 54,
+	}
+
+	correction := 540 * time.Millisecond
+
+	tests := map[string]struct {
+		header  time.Time
+		start   time.Time
+		applied bool
+		value   time.Duration
+	}{
+		"ignore": {header: start, start: start},
+		"record": {header: start, start: start.Add(correction), value: correction},
+		"apply":  {header: start.Add(correction), start: start.Add(correction), applied: true, value: correction},
+	}
+
+	for k, v := range tests {
+		t.Run(k, func(t *testing.T) {
+			var output records
+
+			conv := NewConverter(Settings{blksize: 512, encoding: "int32", timecorrection: k}, &output)
+			if err := conv.Trace(trace); err != nil {
+				t.Fatal(err)
+			}
+			if len(output) != 1 {
+				t.Fatalf("invalid number of records, expected %d got %d", 1, len(output))
+			}
+
+			msr := output[0]
+			if s := msr.RecordStartTime.Time(); !s.Equal(v.header) {
+				t.Errorf("invalid header time, expected %v got %v", v.header, s)
+			}
+			if s := msr.StartTime(); !s.Equal(v.start) {
+				t.Errorf("invalid start time, expected %v got %v", v.start, s)
+			}
+			if c := msr.Correction(); c != v.value {
+				t.Errorf("invalid time correction, expected %v got %v", v.value, c)
+			}
+			if applied := msr.ActivityFlags&0x02 != 0; applied != v.applied {
+				t.Errorf("invalid correction applied flag, expected %v got %v", v.applied, applied)
+			}
+		})
+	}
+}
+
+func TestConvert_TimingQuality(t *testing.T) {
+	trace := earss.Trace{
+		Instrument:     106,
+		SampleRate:     100,
+		Start:          time.Date(1994, 3, 12, 23, 9, 58, 650000000, time.UTC),
+		Samples:        make([]int32, 100),
+		TimeCorrection: 54,
+	}
+
+	quality := 80
+	mapping := Stations{{Instrument: 106, Network: "NZ", Station: "LYLM", TimingQuality: &quality}}
+
+	tests := map[string]struct {
+		settings Settings
+		quality  uint8
+	}{
+		"estimate": {Settings{blksize: 512, encoding: "int32", timingquality: -1}, 36},
+		"setting":  {Settings{blksize: 512, encoding: "int32", timingquality: 50}, 50},
+		"mapping":  {Settings{blksize: 512, encoding: "int32", timingquality: 50, mapping: mapping}, 80},
+	}
+
+	for k, v := range tests {
+		t.Run(k, func(t *testing.T) {
+			var output records
+
+			conv := NewConverter(v.settings, &output)
+			if err := conv.Trace(trace); err != nil {
+				t.Fatal(err)
+			}
+			if len(output) == 0 {
+				t.Fatalf("no records were written")
+			}
+			for _, msr := range output {
+				if !msr.HasBlockette1001() {
+					t.Fatalf("missing blockette 1001")
+				}
+				if q := msr.B1001.TimingQuality; q != v.quality {
+					t.Errorf("invalid timing quality, expected %d got %d", v.quality, q)
+				}
+			}
+		})
+	}
+}
+
+func TestConvert_MeasureRate(t *testing.T) {
+	start := time.Date(1994, 3, 12, 23, 9, 58, 650000000, time.UTC)
+
+	// three buffers of a single channel recorder, each starting 10 ms late
+	count := earss.DataValues
+	trace := earss.Trace{
+		Instrument: 106,
+		SampleRate: 100,
+		Start:      start,
+		Buffers:    3,
+		Samples:    make([]int32, 3*count),
+		Channels:   1,
+	}
+	for i := 0; i < trace.Buffers; i++ {
+		trace.Times = append(trace.Times, start.Add(time.Duration(i)*(time.Duration(count)*10*time.Millisecond+earss.TimeResolution)))
+	}
+
+	rate, ok := trace.MeasuredRate(time.Minute)
+	if !ok {
+		t.Fatal("unable to measure the sample rate")
+	}
+
+	var output records
+
+	conv := NewConverter(Settings{blksize: 512, encoding: "steim2", measurerate: true, measurespan: time.Minute}, &output)
+	if err := conv.Trace(trace); err != nil {
+		t.Fatal(err)
+	}
+	if len(output) == 0 {
+		t.Fatalf("no records were written")
+	}
+
+	var samples int
+	for _, msr := range output {
+		if r := msr.ActualSampleRate(); float32(r) != float32(rate) {
+			t.Fatalf("invalid actual sample rate, expected %g got %g", rate, r)
+		}
+		samples += msr.SampleCount()
+	}
+
+	// the end time should follow the measured rate rather than the nominal rate
+	expected := start.Add(time.Duration(float64(samples-1) / rate * float64(time.Second)))
+	if d := output[len(output)-1].EndTime().Sub(expected); d > time.Millisecond || d < -time.Millisecond {
+		t.Errorf("invalid end time, expected %v got %v", expected, output[len(output)-1].EndTime())
+	}
+
+	// the trace is too short to measure the rate over an hour, so the nominal rate is used.
+	var nominal records
+
+	conv = NewConverter(Settings{blksize: 512, encoding: "steim2", measurerate: true, measurespan: time.Hour}, &nominal)
+	if err := conv.Trace(trace); err != nil {
+		t.Fatal(err)
+	}
+	for _, msr := range nominal {
+		if _, ok := msr.Blockette(100); ok {
+			t.Fatalf("unexpected blockette 100 for a short trace")
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ozym/earss/internal/earss"
+)
+
+// EventFormat is used to build event file names from the event trigger time.
+const EventFormat = "20060102T150405.00"
+
+// EventSummary describes a single event file that has been written.
+type EventSummary struct {
+	Path    string
+	Event   earss.Event
+	Records int
+}
+
+// Events writes each triggered EARSS event into its own miniseed file in the base directory.
+type Events struct {
+	settings Settings
+	base     string
+
+	summary []EventSummary
+	counter int
+}
+
+// NewEvents returns an Events writer that builds event file names relative to the base directory.
+func NewEvents(settings Settings, base string) *Events {
+	return &Events{
+		settings: settings,
+		base:     base,
+	}
+}
+
+// Count returns the number of miniseed records written.
+func (e *Events) Count() int {
+	return e.counter
+}
+
+// Summary returns the events that have been written.
+func (e *Events) Summary() []EventSummary {
+	return e.summary
+}
+
+// Path returns the output file name for an event, this is built from the stream codes of the
+// first channel, the instrument number and the trigger time.
+func (e *Events) Path(ev earss.Event) string {
+	network, station, _, _, _ := e.settings.Codes(ev.Instrument, 0, ev.Trigger)
+
+	ext := "mseed"
+	if e.settings.format == "mseed3" {
+		ext = "mseed3"
+	}
+
+	return filepath.Join(e.base, fmt.Sprintf("%s.%s.%d.%s.%s", network, station, ev.Instrument, ev.Trigger.Format(EventFormat), ext))
+}
+
+// Event converts a single EARSS event into a miniseed file, it is suitable for use as an Extractor callback.
+func (e *Events) Event(ev earss.Event) error {
+	path := e.Path(ev)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// an existing file is never overwritten, as it may hold another event.
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	conv := NewConverter(e.settings, NewStream(file, e.settings.format))
+
+	asm := e.settings.Assembler(conv.Trace)
+	for _, r := range ev.Buffers {
+		if err := asm.Add(r); err != nil {
+			return err
+		}
+	}
+	if err := asm.Flush(); err != nil {
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	e.counter += conv.Count()
+	e.summary = append(e.summary, EventSummary{
+		Path:    path,
+		Event:   ev,
+		Records: conv.Count(),
+	})
+
+	return nil
+}
+
+// WriteSummary writes a listing of the events that have been written.
+func (e *Events) WriteSummary(wr io.Writer) error {
+	tw := tabwriter.NewWriter(wr, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "File\tInstrument\tStart\tTrigger\tDuration\tChannels\tBuffers\tComplete")
+	for _, s := range e.summary {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%v\t%d\t%d\t%v\n",
+			filepath.Base(s.Path),
+			s.Event.Instrument,
+			s.Event.Start.Format(time.RFC3339Nano),
+			s.Event.Trigger.Format(time.RFC3339Nano),
+			s.Event.Duration(),
+			s.Event.NumberOfChannels,
+			len(s.Event.Buffers),
+			s.Event.Complete,
+		)
+	}
+	fmt.Fprintf(tw, "Total: %d event(s)\n", len(e.summary))
+
+	return tw.Flush()
+}
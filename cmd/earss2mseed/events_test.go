@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozym/earss/internal/earss"
+)
+
+func TestEvents_Path(t *testing.T) {
+
+	data, err := os.ReadFile("../../internal/earss/testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := earss.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var list []earss.Event
+	extractor := earss.NewExtractor(func(ev earss.Event) error {
+		list = append(list, ev)
+		return nil
+	})
+	for _, r := range records {
+		if err := extractor.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := extractor.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("invalid number of events, expected %d but got %d", 1, len(list))
+	}
+
+	// the same trigger recorded by another instrument.
+	other := list[0]
+	other.Instrument++
+
+	settings := Settings{
+		network:    "NZ",
+		station:    "LYLM",
+		location:   "10",
+		channel:    "EH",
+		components: "ZNE",
+		blksize:    512,
+		encoding:   "steim2",
+		format:     "mseed2",
+	}
+
+	base := t.TempDir()
+	events := NewEvents(settings, base)
+
+	if p := events.Path(list[0]); p != filepath.Join(base, "NZ.LYLM.106.19940312T231008.65.mseed") {
+		t.Errorf("invalid event path: %s", p)
+	}
+	if events.Path(list[0]) == events.Path(other) {
+		t.Errorf("events from different instruments should not share a path")
+	}
+
+	for _, ev := range []earss.Event{list[0], other} {
+		if err := events.Event(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(events.Summary()); n != 2 {
+		t.Errorf("invalid number of event files, expected %d but got %d", 2, n)
+	}
+
+	// a repeated event must not overwrite the existing file.
+	if err := events.Event(list[0]); err == nil {
+		t.Errorf("expected an error when an event file already exists")
+	}
+}
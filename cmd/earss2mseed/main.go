@@ -9,18 +9,40 @@ import (
 	"time"
 
 	"github.com/ozym/earss/internal/earss"
-	"github.com/ozym/earss/internal/ms"
 )
 
 type Settings struct {
-	verbose bool
-	blksize int
+	verbose   bool
+	blksize   int
+	encoding  string
+	calibrate bool
+	format    string
+
+	timecorrection string
+	timingquality  int
+	measurerate    bool
+	measurespan    time.Duration
+	workers        int
+
+	maxsamples int
+	daily      bool
+
+	manifest string
+	resume   bool
+	resync   bool
 
 	station    string
 	network    string
 	location   string
 	channel    string
 	components string
+
+	stations string
+	mapping  Stations
+
+	output string
+	base   string
+	events bool
 }
 
 func (s Settings) Blksize() int {
@@ -34,6 +56,51 @@ func (s Settings) Channel(offset int) string {
 	return s.channel
 }
 
+// TimingQuality returns the timing quality percentage for an instrument trace, using the station
+// mapping if available, then the timing quality setting, otherwise an estimate from the trace headers.
+func (s Settings) TimingQuality(trace earss.Trace) int {
+	if station, ok := s.mapping.Lookup(trace.Instrument, trace.Start); ok && station.TimingQuality != nil {
+		return *station.TimingQuality
+	}
+	if s.timingquality >= 0 {
+		return s.timingquality
+	}
+	return trace.TimingQuality()
+}
+
+// Assembler returns an earss Assembler that passes traces to the given function, the traces are limited
+// in length to bound memory use when converting long recordings. Traces are only broken at gain changes
+// when the samples are calibrated.
+func (s Settings) Assembler(fn earss.TraceFunc) *earss.Assembler {
+	asm := earss.NewAssembler(0, fn)
+	asm.SetMaxSamples(s.maxsamples)
+	asm.SetDaily(s.daily)
+	asm.SetSplitGains(s.calibrate)
+	return asm
+}
+
+// reportSkipped logs any byte ranges that were skipped while resynchronising an input.
+func reportSkipped(path string, rd earss.Buffers) {
+	sc, ok := rd.(*earss.Scanner)
+	if !ok {
+		return
+	}
+	for _, s := range sc.Skipped() {
+		log.Printf("skipped %d bytes at offset %d in %s", s.Length, s.Offset, path)
+	}
+}
+
+// Codes returns the SEED stream codes for the given instrument channel at a point in time,
+// using the station mapping if available otherwise falling back to the default settings.
+func (s Settings) Codes(instrument, offset int, at time.Time) (string, string, string, string, bool) {
+	if station, ok := s.mapping.Lookup(instrument, at); ok {
+		if channel, ok := station.Channel(offset); ok {
+			return station.Network, station.Station, station.Location, channel, true
+		}
+	}
+	return s.network, s.station, s.location, s.Channel(offset), false
+}
+
 func main() {
 
 	var settings Settings
@@ -46,6 +113,24 @@ func main() {
 		fmt.Fprintf(os.Stderr, "\n")
 		fmt.Fprintf(os.Stderr, "  %s [options] <files...>\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Time Correction:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "  The EARSS header time correction is a signed count of 10 ms units that is added\n")
+		fmt.Fprintf(os.Stderr, "  to the header time, it is converted into the miniseed 0.1 ms units.\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "  ignore  the correction is not used\n")
+		fmt.Fprintf(os.Stderr, "  record  the correction is stored in the header, the start time is not adjusted\n")
+		fmt.Fprintf(os.Stderr, "  apply   the start time is adjusted and the correction applied flag is set\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Workers:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "  With more than one worker each file is assembled independently, so traces and any\n")
+		fmt.Fprintf(os.Stderr, "  steim difference chains restart at the start of every file. The samples are the\n")
+		fmt.Fprintf(os.Stderr, "  same as for a single worker, but records are broken at each file boundary: the\n")
+		fmt.Fprintf(os.Stderr, "  last record from each file is usually only partly filled, and the break is not\n")
+		fmt.Fprintf(os.Stderr, "  reported as a discontinuity, so a day file may hold several such breaks. Use a\n")
+		fmt.Fprintf(os.Stderr, "  single worker, or msrepack the output, if full records are needed.\n")
+		fmt.Fprintf(os.Stderr, "\n")
 		fmt.Fprintf(os.Stderr, "General Options:\n")
 		fmt.Fprintf(os.Stderr, "\n")
 		flag.PrintDefaults()
@@ -59,58 +144,300 @@ func main() {
 	flag.StringVar(&settings.channel, "channel", "EH", "miniseed channel code prefix")
 	flag.StringVar(&settings.components, "components", "ZNE", "miniseed channel code suffix")
 	flag.IntVar(&settings.blksize, "blksize", 512, "miniseed block size in bytes")
+	flag.StringVar(&settings.stations, "stations", "", "optional JSON file mapping instrument ids to miniseed stream codes")
+	flag.StringVar(&settings.output, "output", "", "output file path template, or \"sds\" for an SDS archive, otherwise write to stdout")
+	flag.StringVar(&settings.base, "base", ".", "base directory for output files")
+	flag.StringVar(&settings.encoding, "encoding", "int32", "miniseed data encoding, one of int32, steim1, steim2, float32 or float64")
+	flag.BoolVar(&settings.calibrate, "calibrate", false, "normalise samples by the channel system gain, requires a float32 or float64 encoding")
+	flag.StringVar(&settings.format, "format", "mseed2", "miniseed output format, either mseed2 or mseed3")
+	flag.StringVar(&settings.timecorrection, "timecorrection", "record", "header time correction policy, one of ignore, record or apply")
+	flag.IntVar(&settings.timingquality, "timingquality", -1, "blockette 1001 timing quality percentage, a negative value will estimate it from the time correction")
+	flag.BoolVar(&settings.measurerate, "measurerate", false, "measure the actual sample rate from the buffer start times and store it in a blockette 100")
+	flag.DurationVar(&settings.measurespan, "measurespan", time.Hour, "minimum span of buffer start times needed to measure the sample rate, shorter traces use the nominal rate")
+	flag.IntVar(&settings.maxsamples, "maxsamples", 0, "maximum number of samples held for each channel before packing, zero for no limit")
+	flag.BoolVar(&settings.daily, "daily", true, "pack the samples held for each channel at the start of each UTC day")
+	flag.IntVar(&settings.workers, "workers", 1, "number of files to convert in parallel, files are then assembled independently and written in order, see above")
+	flag.StringVar(&settings.manifest, "manifest", "", "optional JSON file recording the conversion state of each input file, any partial or failed conversions, and anything written after them, are removed before converting, requires an output template")
+	flag.BoolVar(&settings.resume, "resume", false, "skip input files listed as complete in the manifest")
+	flag.BoolVar(&settings.resync, "resync", false, "search damaged or misaligned input files for buffers, reporting any skipped bytes")
+	flag.BoolVar(&settings.events, "events", false, "write each triggered event into its own file in the base directory, and list the events on stdout")
 
 	flag.Parse()
 
-	for _, f := range flag.Args() {
-		if settings.verbose {
-			log.Printf("converting file %s", f)
+	switch settings.encoding {
+	case "int32", "steim1", "steim2":
+		if settings.calibrate {
+			log.Fatalf("calibrated samples require a float32 or float64 encoding, not %q", settings.encoding)
 		}
-		data, err := os.ReadFile(f)
+	case "float32", "float64":
+	default:
+		log.Fatalf("unknown encoding %q, expected int32, steim1, steim2, float32 or float64", settings.encoding)
+	}
+
+	switch settings.format {
+	case "mseed2", "mseed3":
+	default:
+		log.Fatalf("unknown format %q, expected mseed2 or mseed3", settings.format)
+	}
+
+	switch settings.timecorrection {
+	case "ignore", "record", "apply":
+	default:
+		log.Fatalf("unknown time correction policy %q, expected ignore, record or apply", settings.timecorrection)
+	}
+
+	if settings.measurespan < 0 {
+		log.Fatalf("invalid sample rate measurement span %v", settings.measurespan)
+	}
+
+	if settings.maxsamples < 0 {
+		log.Fatalf("invalid maximum number of samples %d", settings.maxsamples)
+	}
+
+	if settings.timingquality > 100 {
+		log.Fatalf("invalid timing quality %d, expected a percentage", settings.timingquality)
+	}
+
+	if settings.events && settings.output != "" {
+		log.Fatalf("the events and output options cannot be used together")
+	}
+	if settings.events && settings.workers > 1 {
+		log.Fatalf("the events option cannot be used with more than one worker")
+	}
+	if settings.manifest != "" && (settings.output == "" || settings.events) {
+		log.Fatalf("the manifest option requires an output template and cannot be used with events")
+	}
+	if settings.resume && settings.manifest == "" {
+		log.Fatalf("the resume option requires a manifest")
+	}
+
+	if settings.stations != "" {
+		stations, err := ReadStations(settings.stations)
 		if err != nil {
 			log.Fatal(err)
 		}
-		records, err := earss.Decode(data)
+		settings.mapping = stations
+	}
+
+	var output Output
+	var router *Router
+	switch settings.output {
+	case "":
+		output = NewStream(os.Stdout, settings.format)
+	case "sds", "SDS":
+		router = NewRouter(settings.base, SDS, settings.blksize, settings.format)
+		output = router
+	default:
+		router = NewRouter(settings.base, settings.output, settings.blksize, settings.format)
+		output = router
+	}
+	defer output.Close()
+
+	paths := flag.Args()
+
+	// partial and failed conversions are removed before any new records are written, whether or not completed
+	// files are to be skipped.
+	var manifest *Manifest
+	if settings.manifest != "" {
+		m, err := ReadManifest(settings.manifest)
 		if err != nil {
 			log.Fatal(err)
 		}
-
-		if settings.verbose {
-			log.Printf("read %d records from %s", len(records), f)
+		if err := m.Rollback(); err != nil {
+			log.Fatal(err)
 		}
+		manifest = m
+	}
 
-		var counter int
-		for _, record := range records {
-			if !(record.Instrument > 0) {
+	if settings.resume {
+		var pending []string
+		for _, f := range paths {
+			done, err := manifest.Completed(f)
+			if err != nil {
+				log.Printf("unable to check %s: %v", f, err)
+			}
+			if done {
+				if settings.verbose {
+					log.Printf("skipping completed file %s", f)
+				}
 				continue
 			}
-			for channel := 0; channel < record.NumberOfChannels; channel++ {
-				var samples []int32
-				for i := 0; i < earss.DataValues; i += record.NumberOfChannels {
-					samples = append(samples, int32(record.Samples[i+channel]))
+			pending = append(pending, f)
+		}
+		paths = pending
+	}
+
+	// the manifest records the output files that each input file has been written into.
+	out := output
+
+	var tracker *Tracker
+	if manifest != nil {
+		tracker = NewTracker(router, manifest)
+		out = tracker
+	}
+
+	conv := NewConverter(settings, out)
+	asm := settings.Assembler(conv.Trace)
+
+	events := NewEvents(settings, settings.base)
+	extractor := earss.NewExtractor(events.Event)
+
+	add, flush := asm.Add, asm.Flush
+	if settings.events {
+		add, flush = extractor.Add, extractor.Flush
+	}
+
+	// files that could not be converted are reported rather than stopping the batch.
+	var failed []string
+
+	var counter int
+	switch {
+	case settings.workers > 1:
+		if err := Pipeline(settings, paths, settings.workers, func(res FileResult) error {
+			if res.Err != nil {
+				log.Printf("unable to convert %s: %v", res.Path, res.Err)
+				failed = append(failed, res.Path)
+				if manifest != nil {
+					return manifest.Fail(res)
 				}
-				rec := ms.NewEmptyRecord(settings.Blksize(), record.SampleRate, 1)
-				start := record.StartTime.Add(-time.Second * time.Duration(record.PreEventSeconds))
-				copy(rec.NetworkIdentifier[:], []byte(settings.network))
-				copy(rec.StationIdentifier[:], []byte(settings.station))
-				copy(rec.LocationIdentifier[:], []byte(settings.location))
-				copy(rec.ChannelIdentifier[:], []byte(settings.Channel(channel)))
-				rec.TimeCorrection = int32(100 * record.TimeCorrection)
-				if err := rec.PackInt32(start, samples, func(msr *ms.Record) error {
-					copy(msr.SequenceNumber[:], []byte(fmt.Sprintf("%06d", counter+1)))
-					counter++
-					return msr.Encode(os.Stdout)
-				}); err != nil {
-					log.Fatal(err)
+				return nil
+			}
+
+			if tracker != nil {
+				if err := tracker.Begin(res.Path); err != nil {
+					return err
 				}
+				if err := tracker.Converted(res, 0); err != nil {
+					return err
+				}
+			}
+
+			for _, msr := range res.Records {
+				counter++
+				msr.SetSeqNumber(counter)
+				if err := out.Write(msr); err != nil {
+					return err
+				}
+			}
+
+			if tracker != nil {
+				if err := tracker.Finish(); err != nil {
+					return err
+				}
+			}
+
+			if settings.verbose {
+				reportAssembly(res.Tolerance, res.Discontinuities, res.GainChanges)
+				log.Printf("read %d records from %s", res.Buffers, res.Path)
 			}
+			return nil
+		}); err != nil {
+			log.Fatal(err)
 		}
-		if settings.verbose {
-			log.Printf("packed %d blocks from %s", counter, f)
+	default:
+		list, err := convertFiles(settings, paths, asm, add, tracker)
+		if err != nil {
+			log.Fatal(err)
 		}
+		failed = append(failed, list...)
+	}
+
+	if err := flush(); err != nil {
+		log.Fatal(err)
+	}
+
+	if tracker != nil {
+		if err := tracker.Finish(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if settings.events {
+		if err := events.WriteSummary(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if settings.verbose {
+		reportAssembly(asm.Tolerance(), asm.Discontinuities(), asm.GainChanges())
+		log.Printf("packed %d blocks", conv.Count()+events.Count()+counter)
+	}
+
+	if err := output.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	if len(failed) > 0 {
+		log.Fatalf("unable to convert %d of %d files", len(failed), len(flag.Args()))
 	}
 
 	if settings.verbose {
 		log.Println("conversion complete.")
 	}
 }
+
+// convertFiles converts the files in order, passing each buffer to the add function, the paths of any files
+// that could not be converted are returned. Traces can join buffers from consecutive files, so when a tracker
+// is given a file is only finished in the manifest once all of its buffers, and no later ones, have been
+// written by the assembler.
+func convertFiles(settings Settings, paths []string, asm *earss.Assembler, add func(earss.Record) error, tracker *Tracker) ([]string, error) {
+	var buffers int
+	if tracker != nil {
+		add = func(r earss.Record) error {
+			if err := asm.Add(r); err != nil {
+				return err
+			}
+			buffers++
+			if n, ok := asm.Checkpoint(); ok {
+				return tracker.Checkpoint(n)
+			}
+			return nil
+		}
+	}
+
+	var failed []string
+	for _, f := range paths {
+		if settings.verbose {
+			log.Printf("converting file %s", f)
+		}
+		if tracker != nil {
+			if err := tracker.Begin(f); err != nil {
+				return nil, err
+			}
+		}
+		res := convertFile(settings, f, add)
+		if tracker != nil {
+			if err := tracker.Converted(res, buffers); err != nil {
+				return nil, err
+			}
+		}
+		if res.Err != nil {
+			log.Printf("unable to convert %s: %v", f, res.Err)
+			failed = append(failed, f)
+			continue
+		}
+		if settings.verbose {
+			log.Printf("read %d records from %s", res.Buffers, f)
+		}
+	}
+
+	return failed, nil
+}
+
+// reportAssembly logs any discontinuities and gain changes found while assembling traces.
+func reportAssembly(tolerance time.Duration, breaks []earss.Discontinuity, gains []earss.GainChange) {
+	for _, d := range breaks {
+		switch {
+		case d.IsGap(tolerance):
+			log.Printf("instrument %d: gap of %v before buffer %d at %s", d.Instrument, d.Gap(), d.Buffer, d.Start.Format(time.RFC3339Nano))
+		case d.IsOverlap(tolerance):
+			log.Printf("instrument %d: overlap of %v before buffer %d at %s", d.Instrument, -d.Gap(), d.Buffer, d.Start.Format(time.RFC3339Nano))
+		default:
+			log.Printf("instrument %d: break before buffer %d at %s", d.Instrument, d.Buffer, d.Start.Format(time.RFC3339Nano))
+		}
+	}
+	for _, g := range gains {
+		log.Printf("instrument %d: channel %d gain changed from %d to %d at buffer %d at %s",
+			g.Instrument, g.Channel, earss.GainSystem[g.From], earss.GainSystem[g.To], g.Buffer, g.At.Format(time.RFC3339Nano))
+	}
+}
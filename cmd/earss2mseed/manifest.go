@@ -0,0 +1,409 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ozym/earss/internal/ms"
+)
+
+const (
+	StatusStarted  = "started"
+	StatusComplete = "complete"
+	StatusFailed   = "failed"
+)
+
+// OutputFile is a miniseed file that an input has been written into, the offset is the size of
+// the file before any records from the input were appended.
+type OutputFile struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+}
+
+// ManifestEntry records the conversion of a single input file.
+type ManifestEntry struct {
+	Path    string       `json:"path"`
+	Size    int64        `json:"size"`
+	Hash    string       `json:"hash"`
+	Buffers int          `json:"buffers"`
+	Records int          `json:"records"`
+	Outputs []OutputFile `json:"outputs,omitempty"`
+	Status  string       `json:"status"`
+	Error   string       `json:"error,omitempty"`
+	Updated time.Time    `json:"updated"`
+}
+
+// Manifest is a JSON state file that lists the conversion status of each input file, it is saved
+// whenever an entry changes so that an interrupted batch can be resumed.
+type Manifest struct {
+	path string
+
+	Entries []*ManifestEntry `json:"entries"`
+}
+
+// NewManifest returns an empty Manifest that will be saved into the given file.
+func NewManifest(path string) *Manifest {
+	return &Manifest{path: path}
+}
+
+// ReadManifest loads a Manifest from a file, an empty Manifest is returned if the file does not exist.
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return NewManifest(path), nil
+	case err != nil:
+		return nil, err
+	}
+
+	m := NewManifest(path)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// Save writes the Manifest, a temporary file is renamed over the original so that an interruption
+// will not leave a partially written Manifest behind.
+func (m *Manifest) Save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, m.path)
+}
+
+// key returns the path used to identify an input file.
+func key(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// Lookup returns the entry for an input file, if present.
+func (m *Manifest) Lookup(path string) (*ManifestEntry, bool) {
+	k := key(path)
+	for _, e := range m.Entries {
+		if e.Path == k {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// entry returns the entry for an input file, one is added if not already present.
+func (m *Manifest) entry(path string) *ManifestEntry {
+	if e, ok := m.Lookup(path); ok {
+		return e
+	}
+	e := &ManifestEntry{Path: key(path)}
+	m.Entries = append(m.Entries, e)
+	return e
+}
+
+// Completed returns whether the input file has been fully converted and is unchanged since then.
+func (m *Manifest) Completed(path string) (bool, error) {
+	e, ok := m.Lookup(path)
+	if !ok || e.Status != StatusComplete {
+		return false, nil
+	}
+
+	size, hash, err := HashFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	return e.Size == size && e.Hash == hash, nil
+}
+
+// Rollback truncates the output files of any partially converted or failed inputs back to their size before
+// the conversion started, this needs to be done before any new records are written. As several inputs
+// may have been started at once each output file is truncated to the smallest recorded size, any completed
+// input that wrote into an output file after that point is also removed and will need converting again.
+func (m *Manifest) Rollback() error {
+	offsets := make(map[string]int64)
+
+	remove := func(e *ManifestEntry, reason string) {
+		for _, o := range e.Outputs {
+			if offset, ok := offsets[o.Path]; !ok || o.Offset < offset {
+				offsets[o.Path] = o.Offset
+			}
+		}
+		e.Outputs, e.Records = nil, 0
+		e.Status, e.Updated = StatusFailed, time.Now().UTC()
+		if e.Error == "" {
+			e.Error = reason
+		}
+	}
+
+	for _, e := range m.Entries {
+		switch {
+		case e.Status == StatusStarted:
+			e.Error = ""
+			remove(e, "interrupted")
+		case e.Status == StatusFailed && len(e.Outputs) > 0:
+			remove(e, "failed")
+		}
+	}
+
+	// removing a completed input may truncate further output files, so this is repeated until nothing changes.
+	for changed := true; changed; {
+		changed = false
+		for _, e := range m.Entries {
+			if e.Status != StatusComplete {
+				continue
+			}
+			for _, o := range e.Outputs {
+				if offset, ok := offsets[o.Path]; ok && o.Offset >= offset {
+					remove(e, "rolled back")
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	for path, offset := range offsets {
+		switch info, err := os.Stat(path); {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return err
+		case info.Size() > offset:
+			if err := os.Truncate(path, offset); err != nil {
+				return err
+			}
+		}
+	}
+
+	return m.Save()
+}
+
+// Begin marks an input file as started, any records should then be written via a Tracker so that the
+// output files are recorded.
+func (m *Manifest) Begin(path string) (*ManifestEntry, error) {
+	e := m.entry(path)
+
+	e.Size, e.Hash = 0, ""
+	e.Buffers, e.Records = 0, 0
+	e.Outputs, e.Error = nil, ""
+	e.Status, e.Updated = StatusStarted, time.Now().UTC()
+
+	return e, m.Save()
+}
+
+// Update stores the size, checksum and buffer count of a converted input file, and any error that
+// stopped the conversion.
+func (m *Manifest) Update(e *ManifestEntry, res FileResult) error {
+	e.Size, e.Hash = res.Size, res.Hash
+	e.Buffers = res.Buffers
+	if res.Err != nil {
+		e.Error = res.Err.Error()
+	}
+	e.Updated = time.Now().UTC()
+
+	return m.Save()
+}
+
+// Finish marks the entry as having been fully converted, or as having failed if an error was stored.
+func (m *Manifest) Finish(e *ManifestEntry) error {
+	e.Status, e.Updated = StatusComplete, time.Now().UTC()
+	if e.Error != "" {
+		e.Status = StatusFailed
+	}
+	return m.Save()
+}
+
+// Fail marks the input file of a result as having failed.
+func (m *Manifest) Fail(res FileResult) error {
+	e := m.entry(res.Path)
+
+	e.Size, e.Hash = res.Size, res.Hash
+	e.Buffers, e.Records = res.Buffers, 0
+	e.Outputs = nil
+	if res.Err != nil {
+		e.Error = res.Err.Error()
+	}
+	e.Status, e.Updated = StatusFailed, time.Now().UTC()
+
+	return m.Save()
+}
+
+// HashFile returns the size and SHA-256 checksum of a file.
+func HashFile(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Tracker is an Output that writes records using a Router, the output files and their sizes before
+// the first record is written are added to the manifest entry of each open input. As traces may join
+// buffers from consecutive inputs, more than one input can be open at a time.
+type Tracker struct {
+	router   *Router
+	manifest *Manifest
+
+	entries []*ManifestEntry
+	ends    []int
+}
+
+// NewTracker returns a Tracker that records the Router output files in the manifest.
+func NewTracker(router *Router, manifest *Manifest) *Tracker {
+	return &Tracker{
+		router:   router,
+		manifest: manifest,
+	}
+}
+
+// Begin opens a manifest entry for an input file, any records written are counted against it.
+func (t *Tracker) Begin(path string) error {
+	e, err := t.manifest.Begin(path)
+	if err != nil {
+		return err
+	}
+	t.entries, t.ends = append(t.entries, e), append(t.ends, -1)
+
+	return nil
+}
+
+// Converted updates the latest entry once its input has been read, end is the number of buffers read
+// from all the inputs so far and is used to decide when all of the input buffers have been written.
+func (t *Tracker) Converted(res FileResult, end int) error {
+	if len(t.entries) == 0 {
+		return fmt.Errorf("no open manifest entry for %s", res.Path)
+	}
+	t.ends[len(t.ends)-1] = end
+
+	return t.manifest.Update(t.entries[len(t.entries)-1], res)
+}
+
+// Checkpoint is given the number of buffers that have been written, which must be the only buffers written.
+// If this is the end of an open entry, it and any earlier entries are finished. The remaining entries have
+// then had none of their buffers written, so their output files are recorded again from the current sizes.
+func (t *Tracker) Checkpoint(n int) error {
+	var done int
+	for done < len(t.entries) && t.ends[done] >= 0 && t.ends[done] <= n {
+		done++
+	}
+	if done == 0 || t.ends[done-1] != n {
+		return nil
+	}
+
+	if err := t.finish(t.entries[:done]); err != nil {
+		return err
+	}
+	t.entries, t.ends = t.entries[done:], t.ends[done:]
+
+	for _, e := range t.entries {
+		e.Outputs = nil
+	}
+
+	return t.manifest.Save()
+}
+
+// Finish finishes all the open entries, this should be called once all the records have been written.
+func (t *Tracker) Finish() error {
+	if err := t.finish(t.entries); err != nil {
+		return err
+	}
+	t.entries, t.ends = nil, nil
+
+	return nil
+}
+
+// finish finishes a group of entries, as their records may have been joined into the same traces the whole
+// group is marked as failed if any one of the inputs failed. The output files are kept so that the records
+// can be rolled back.
+func (t *Tracker) finish(entries []*ManifestEntry) error {
+	var failed string
+	for _, e := range entries {
+		if e.Error != "" {
+			failed = e.Path
+			break
+		}
+	}
+
+	for _, e := range entries {
+		if failed != "" && e.Error == "" {
+			e.Error = fmt.Sprintf("joined with failed input %s", failed)
+		}
+		if err := t.manifest.Finish(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write adds any new output file to the open manifest entries before the record is written.
+func (t *Tracker) Write(msr *ms.Record) error {
+	path := t.router.Path(msr)
+
+	var changed bool
+	for _, e := range t.entries {
+		var found bool
+		for _, o := range e.Outputs {
+			if o.Path == path {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		var offset int64
+		switch info, err := os.Stat(path); {
+		case err == nil:
+			offset = info.Size()
+		case !errors.Is(err, os.ErrNotExist):
+			return err
+		}
+		e.Outputs, changed = append(e.Outputs, OutputFile{Path: path, Offset: offset}), true
+	}
+
+	if changed {
+		if err := t.manifest.Save(); err != nil {
+			return err
+		}
+	}
+
+	if err := t.router.Write(msr); err != nil {
+		return err
+	}
+	if n := len(t.entries); n > 0 {
+		t.entries[n-1].Records++
+	}
+
+	return nil
+}
+
+// Close is a no-op as the underlying Router is not owned by the Tracker.
+func (t *Tracker) Close() error {
+	return nil
+}
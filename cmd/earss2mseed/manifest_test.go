@@ -0,0 +1,415 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ozym/earss/internal/earss"
+	"github.com/ozym/earss/internal/ms"
+)
+
+func TestManifest_Resume(t *testing.T) {
+	dir := t.TempDir()
+
+	input := filepath.Join(dir, "input.dat")
+	data, err := os.ReadFile("../../internal/earss/testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(input, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := Settings{blksize: 512, encoding: "steim2", timingquality: -1, network: "XX", station: "TEST", channel: "EH", components: "ZNE"}
+
+	res := ConvertFile(settings, input)
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	if size, hash, err := HashFile(input); err != nil || size != res.Size || hash != res.Hash {
+		t.Fatalf("invalid input checksum, expected %d %s got %d %s", size, hash, res.Size, res.Hash)
+	}
+
+	router := NewRouter(filepath.Join(dir, "out"), "%s.%c", settings.blksize, "mseed2")
+	defer router.Close()
+
+	write := func(m *Manifest, finish bool) {
+		tracker := NewTracker(router, m)
+		if err := tracker.Begin(input); err != nil {
+			t.Fatal(err)
+		}
+		if err := tracker.Converted(res, res.Buffers); err != nil {
+			t.Fatal(err)
+		}
+		for _, msr := range res.Records {
+			if err := tracker.Write(msr); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !finish {
+			return
+		}
+		if err := tracker.Finish(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sizes := func(m *Manifest) map[string]int64 {
+		e, ok := m.Lookup(input)
+		if !ok {
+			t.Fatalf("missing manifest entry for %s", input)
+		}
+		list := make(map[string]int64)
+		for _, o := range e.Outputs {
+			info, err := os.Stat(o.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			list[o.Path] = info.Size()
+		}
+		return list
+	}
+
+	path := filepath.Join(dir, "manifest.json")
+
+	// a complete conversion followed by one that was interrupted
+	m := NewManifest(path)
+	write(m, true)
+	complete := sizes(m)
+	write(m, false)
+
+	m, err = ReadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := m.Lookup(input); !ok || e.Status != StatusStarted || len(e.Outputs) != 3 {
+		t.Fatalf("invalid manifest entry: %+v", e)
+	}
+	if done, err := m.Completed(input); err != nil || done {
+		t.Errorf("a partial conversion should not be complete")
+	}
+
+	if err := m.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	for p, size := range complete {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() != size {
+			t.Errorf("invalid output size for %s after rollback, expected %d got %d", p, size, info.Size())
+		}
+	}
+
+	write(m, true)
+	if done, err := m.Completed(input); err != nil || !done {
+		t.Errorf("the conversion should be complete")
+	}
+
+	// a changed input needs to be converted again
+	if err := os.WriteFile(input, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if done, err := m.Completed(input); err != nil || done {
+		t.Errorf("a changed input should not be complete")
+	}
+}
+
+func TestManifest_Tracker(t *testing.T) {
+	dir := t.TempDir()
+
+	settings := Settings{blksize: 512, encoding: "steim2", timingquality: -1, network: "XX", station: "TEST", channel: "EH", components: "Z"}
+
+	res := ConvertFile(settings, "../../internal/earss/testdata/lylm0313.dat")
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+
+	// records for a single output file.
+	var list []*ms.Record
+	for _, msr := range res.Records {
+		if msr.Channel() == "EHZ" {
+			list = append(list, msr)
+		}
+	}
+	if len(list) < 3 {
+		t.Fatalf("not enough records, expected at least %d got %d", 3, len(list))
+	}
+
+	router := NewRouter(dir, "%s.%c", settings.blksize, "mseed2")
+	defer router.Close()
+
+	output := filepath.Join(dir, "TEST.EHZ")
+	size := func() int64 {
+		info, err := os.Stat(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Size()
+	}
+
+	path := filepath.Join(dir, "manifest.json")
+
+	m := NewManifest(path)
+	tracker := NewTracker(router, m)
+
+	// the first input has two buffers, the first of which has been written before the second input starts.
+	if err := tracker.Begin("first.dat"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Write(list[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Converted(FileResult{Path: "first.dat"}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Begin("second.dat"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Write(list[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	// only part of the first input has been written.
+	if err := tracker.Checkpoint(1); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := m.Lookup("first.dat"); !ok || e.Status != StatusStarted {
+		t.Fatalf("the first input should not be finished: %+v", e)
+	}
+
+	if err := tracker.Checkpoint(2); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := m.Lookup("first.dat"); !ok || e.Status != StatusComplete {
+		t.Fatalf("the first input should be finished: %+v", e)
+	}
+
+	checkpoint := size()
+	if err := tracker.Write(list[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	// an interruption should only remove the records written after the first input was finished.
+	m, err := ReadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if s := size(); s != checkpoint {
+		t.Errorf("invalid output size after rollback, expected %d got %d", checkpoint, s)
+	}
+	if e, ok := m.Lookup("second.dat"); !ok || e.Status != StatusFailed {
+		t.Errorf("the second input should have failed: %+v", e)
+	}
+}
+
+func TestManifest_Rollback(t *testing.T) {
+	dir := t.TempDir()
+
+	output := filepath.Join(dir, "output.mseed")
+	if err := os.WriteFile(output, make([]byte, 300), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// two inputs that were started together, the output should be truncated to the earliest size.
+	m := NewManifest(filepath.Join(dir, "manifest.json"))
+	m.Entries = []*ManifestEntry{
+		{Path: "second.dat", Status: StatusStarted, Outputs: []OutputFile{{Path: output, Offset: 200}}},
+		{Path: "first.dat", Status: StatusStarted, Outputs: []OutputFile{{Path: output, Offset: 100}}},
+		{Path: "missing.dat", Status: StatusStarted, Outputs: []OutputFile{{Path: filepath.Join(dir, "missing.mseed"), Offset: 100}}},
+	}
+
+	if err := m.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 100 {
+		t.Errorf("invalid output size after rollback, expected %d got %d", 100, info.Size())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.mseed")); !os.IsNotExist(err) {
+		t.Errorf("a missing output should not be created")
+	}
+}
+
+func TestManifest_FailedInput(t *testing.T) {
+
+	data, err := os.ReadFile("../../internal/earss/testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := earss.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// six consecutive buffers, with a gap before the last two so that they form a separate trace.
+	period := records[1].Start().Sub(records[0].Start())
+
+	var list []earss.Record
+	for i := 0; i < 6; i++ {
+		r := records[i%len(records)]
+		if i > 0 {
+			r.PreEventSeconds, r.BufferNumber = 0, i+1
+			r.StartTime = records[0].Start().Add(time.Duration(i) * period)
+		}
+		if i >= 4 {
+			r.StartTime = r.StartTime.Add(time.Hour)
+		}
+		list = append(list, r)
+	}
+
+	// the second input fails after its buffers have been added, when a short buffer is found.
+	inputs := func(dir string, damaged bool) []string {
+		var paths []string
+		for i, name := range []string{"a.dat", "b.dat", "c.dat"} {
+			raw, err := earss.Encode(list[2*i : 2*i+2])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if damaged && name == "b.dat" {
+				raw = append(raw, make([]byte, earss.BufferLength/2)...)
+			}
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, raw, 0644); err != nil {
+				t.Fatal(err)
+			}
+			paths = append(paths, path)
+		}
+		return paths
+	}
+
+	settings := Settings{blksize: 512, encoding: "steim2", timingquality: -1, network: "XX", station: "TEST", channel: "EH", components: "ZNE"}
+
+	// run follows the sequential conversion, optionally skipping completed inputs.
+	run := func(out, manifest string, paths []string, resume bool) []string {
+		var m *Manifest
+		if manifest != "" {
+			if m, err = ReadManifest(manifest); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.Rollback(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if resume {
+			var pending []string
+			for _, p := range paths {
+				if done, err := m.Completed(p); err != nil || !done {
+					pending = append(pending, p)
+				}
+			}
+			paths = pending
+		}
+
+		router := NewRouter(out, "%s.%c", settings.blksize, "mseed2")
+
+		var output Output = router
+
+		var tracker *Tracker
+		if m != nil {
+			tracker = NewTracker(router, m)
+			output = tracker
+		}
+
+		asm := settings.Assembler(NewConverter(settings, output).Trace)
+
+		failed, err := convertFiles(settings, paths, asm, asm.Add, tracker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := asm.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if tracker != nil {
+			if err := tracker.Finish(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := router.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		return failed
+	}
+
+	contents := func(dir string) map[string]string {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list := make(map[string]string)
+		for _, f := range files {
+			raw, err := os.ReadFile(filepath.Join(dir, f.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			list[f.Name()] = string(raw)
+		}
+		return list
+	}
+
+	status := func(path string) string {
+		m, err := ReadManifest(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var list []string
+		for _, e := range m.Entries {
+			list = append(list, filepath.Base(e.Path)+":"+e.Status)
+		}
+		return strings.Join(list, " ")
+	}
+
+	// a clean conversion of all the inputs.
+	ref := t.TempDir()
+	if failed := run(filepath.Join(ref, "out"), "", inputs(ref, false), false); len(failed) != 0 {
+		t.Fatalf("unexpected failed inputs: %v", failed)
+	}
+	expected := contents(filepath.Join(ref, "out"))
+
+	dir := t.TempDir()
+	out, manifest := filepath.Join(dir, "out"), filepath.Join(dir, "manifest.json")
+
+	paths := inputs(dir, true)
+	if failed := run(out, manifest, paths, false); len(failed) != 1 || failed[0] != paths[1] {
+		t.Fatalf("invalid failed inputs, expected %v but got %v", paths[1:2], failed)
+	}
+	// the first input was joined with the failed one, the last was written after it.
+	if s := status(manifest); s != "a.dat:failed b.dat:failed c.dat:complete" {
+		t.Errorf("invalid manifest status: %s", s)
+	}
+	partial := contents(out)
+
+	// resuming with the same inputs should give the same output, without repeating any records.
+	if failed := run(out, manifest, paths, true); len(failed) != 1 {
+		t.Fatalf("invalid failed inputs, expected %v but got %v", paths[1:2], failed)
+	}
+	if c := contents(out); !reflect.DeepEqual(c, partial) {
+		t.Errorf("resuming a failed input should not change the output")
+	}
+
+	// once the input has been repaired, resuming should match the clean conversion.
+	inputs(dir, false)
+	if failed := run(out, manifest, paths, true); len(failed) != 0 {
+		t.Fatalf("unexpected failed inputs: %v", failed)
+	}
+	if c := contents(out); !reflect.DeepEqual(c, expected) {
+		t.Errorf("resuming a repaired input should match a clean conversion")
+	}
+	if s := status(manifest); s != "a.dat:complete b.dat:complete c.dat:complete" {
+		t.Errorf("invalid manifest status: %s", s)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozym/earss/internal/ms"
+)
+
+// SDS is the path template for a SeisComP Data Structure archive.
+const SDS = "%Y/%n/%s/%c.D/%n.%s.%l.%c.D.%Y.%j"
+
+// maxOpenFiles limits the number of output files held open at any one time.
+const maxOpenFiles = 64
+
+// marshal encodes a record using the given output format, either mseed2 or mseed3.
+func marshal(format string, msr *ms.Record) ([]byte, error) {
+	if format != "mseed3" {
+		return msr.Marshal()
+	}
+	msr3, err := msr.Record3()
+	if err != nil {
+		return nil, err
+	}
+	return msr3.Marshal()
+}
+
+// Output is used to write miniseed records.
+type Output interface {
+	Write(*ms.Record) error
+	Close() error
+}
+
+// Stream writes miniseed records into a single io.Writer.
+type Stream struct {
+	wr     io.Writer
+	format string
+}
+
+// NewStream returns a Stream that writes records to the given io.Writer using the output format.
+func NewStream(wr io.Writer, format string) *Stream {
+	return &Stream{wr: wr, format: format}
+}
+
+// Write encodes the record into the underlying io.Writer.
+func (s *Stream) Write(msr *ms.Record) error {
+	data, err := marshal(s.format, msr)
+	if err != nil {
+		return err
+	}
+	if _, err := s.wr.Write(data); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Close is a no-op as the underlying io.Writer is not owned by the Stream.
+func (s *Stream) Close() error {
+	return nil
+}
+
+// Router writes miniseed records into files named from a path template, records are appended
+// to any existing files. The template is expanded using the record header values:
+//
+//	%n network code
+//	%s station code
+//	%l location code
+//	%c channel code
+//	%q quality indicator
+//	%Y four digit year
+//	%y two digit year
+//	%j three digit day of year
+//	%m two digit month
+//	%d two digit day of month
+//	%H two digit hour
+//	%% a literal percent sign
+type Router struct {
+	base     string
+	template string
+	blksize  int
+	format   string
+
+	files map[string]*os.File
+}
+
+// NewRouter returns a Router that builds file names relative to the base directory, the
+// block size is used to check that existing files only contain complete records. As mseed3
+// records have variable lengths this check is skipped for that output format.
+func NewRouter(base, template string, blksize int, format string) *Router {
+	if format == "mseed3" {
+		blksize = 0
+	}
+	return &Router{
+		base:     base,
+		template: template,
+		blksize:  blksize,
+		format:   format,
+		files:    make(map[string]*os.File),
+	}
+}
+
+// Path returns the output file name for the given record.
+func (r *Router) Path(msr *ms.Record) string {
+	at := msr.StartTime()
+
+	var sb strings.Builder
+	for i := 0; i < len(r.template); i++ {
+		if r.template[i] != '%' || i+1 == len(r.template) {
+			sb.WriteByte(r.template[i])
+			continue
+		}
+		i++
+		switch r.template[i] {
+		case 'n':
+			sb.WriteString(msr.Network())
+		case 's':
+			sb.WriteString(msr.Station())
+		case 'l':
+			sb.WriteString(msr.Location())
+		case 'c':
+			sb.WriteString(msr.Channel())
+		case 'q':
+			sb.WriteByte(msr.DataQualityIndicator)
+		case 'Y':
+			sb.WriteString(fmt.Sprintf("%04d", at.Year()))
+		case 'y':
+			sb.WriteString(fmt.Sprintf("%02d", at.Year()%100))
+		case 'j':
+			sb.WriteString(fmt.Sprintf("%03d", at.YearDay()))
+		case 'm':
+			sb.WriteString(fmt.Sprintf("%02d", int(at.Month())))
+		case 'd':
+			sb.WriteString(fmt.Sprintf("%02d", at.Day()))
+		case 'H':
+			sb.WriteString(fmt.Sprintf("%02d", at.Hour()))
+		default:
+			sb.WriteByte('%')
+			if c := r.template[i]; c != '%' {
+				sb.WriteByte(c)
+			}
+		}
+	}
+
+	return filepath.Join(r.base, sb.String())
+}
+
+func (r *Router) open(path string) (*os.File, error) {
+	if file, ok := r.files[path]; ok {
+		return file, nil
+	}
+
+	if len(r.files) >= maxOpenFiles {
+		if err := r.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	// avoid appending onto a partially written record.
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if r.blksize > 0 && info.Size()%int64(r.blksize) != 0 {
+		file.Close()
+		return nil, fmt.Errorf("unable to append to %s, size %d is not a multiple of %d", path, info.Size(), r.blksize)
+	}
+
+	r.files[path] = file
+
+	return file, nil
+}
+
+// Write appends the record to its output file, the record is written in a single call
+// so that an interrupted conversion can only leave whole records behind.
+func (r *Router) Write(msr *ms.Record) error {
+	file, err := r.open(r.Path(msr))
+	if err != nil {
+		return err
+	}
+
+	data, err := marshal(r.format, msr)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Close closes all open output files.
+func (r *Router) Close() error {
+	var res error
+	for path, file := range r.files {
+		if err := file.Close(); err != nil && res == nil {
+			res = err
+		}
+		delete(r.files, path)
+	}
+	return res
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ozym/earss/internal/earss"
+	"github.com/ozym/earss/internal/ms"
+)
+
+func TestRouter_Path(t *testing.T) {
+
+	msr := ms.NewEmptyRecord(9, 100, 1)
+	msr.SetNetwork("NZ")
+	msr.SetStation("LYLM")
+	msr.SetLocation("10")
+	msr.SetChannel("EHZ")
+	msr.SetQualityIndication('D')
+	msr.SetStartTime(time.Date(1994, 3, 12, 23, 9, 58, 650000000, time.UTC))
+
+	tests := map[string]string{
+		SDS:                    "1994/NZ/LYLM/EHZ.D/NZ.LYLM.10.EHZ.D.1994.071",
+		"%y%m%d%H.%q":          "94031223.D",
+		"%n_%s_%l_%c.100%%":    "NZ_LYLM_10_EHZ.100%",
+		"%x/%Y-%j.mseed%":      "%x/1994-071.mseed%",
+		"plain/name.mseed":     "plain/name.mseed",
+		"%Y/%j/%n.%s.%l.%c.ms": "1994/071/NZ.LYLM.10.EHZ.ms",
+	}
+
+	for template, path := range tests {
+		t.Run(template, func(t *testing.T) {
+			router := NewRouter("base", template, 512, "mseed2")
+			if p := router.Path(msr); p != filepath.Join("base", path) {
+				t.Errorf("invalid path, expected %q but got %q", filepath.Join("base", path), p)
+			}
+		})
+	}
+
+	t.Run("correction", func(t *testing.T) {
+		rec := *msr
+		rec.SetStartTime(time.Date(1994, 3, 12, 23, 59, 59, 900000000, time.UTC))
+		rec.SetCorrection(540*time.Millisecond, false)
+
+		router := NewRouter("", "%Y.%j", 512, "mseed2")
+		if p := router.Path(&rec); p != "1994.072" {
+			t.Errorf("invalid path, expected %q but got %q", "1994.072", p)
+		}
+	})
+}
+
+func TestConvert_SplitDays(t *testing.T) {
+
+	// the header time is before midnight, but the corrected time of most samples is after it.
+	trace := earss.Trace{
+		Instrument:     106,
+		SampleRate:     100,
+		Start:          time.Date(1994, 3, 12, 23, 59, 59, 0, time.UTC),
+		Samples:        make([]int32, 1000),
+		TimeCorrection: 54,
+	}
+
+	for _, policy := range []string{"ignore", "record", "apply"} {
+		t.Run(policy, func(t *testing.T) {
+			var output records
+
+			settings := Settings{blksize: 512, encoding: "int32", timecorrection: policy, output: "%Y.%j"}
+
+			conv := NewConverter(settings, &output)
+			if err := conv.Trace(trace); err != nil {
+				t.Fatal(err)
+			}
+
+			router := NewRouter("", settings.output, settings.blksize, "mseed2")
+
+			var count int
+			for _, msr := range output {
+				start, end := msr.StartTime(), msr.EndTime()
+				if start.YearDay() != end.YearDay() {
+					t.Errorf("record crosses a day boundary: %v to %v", start, end)
+				}
+				if p, d := router.Path(&msr), start.Format("2006.002"); p != d {
+					t.Errorf("invalid record path, expected %q but got %q", d, p)
+				}
+				count += msr.SampleCount()
+			}
+			if count != len(trace.Samples) {
+				t.Errorf("invalid sample count, expected %d but got %d", len(trace.Samples), count)
+			}
+		})
+	}
+}
+
+func TestConvert_MappingWindows(t *testing.T) {
+
+	change := time.Date(1994, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// the trace starts in the first mapping window and ends in the second.
+	trace := earss.Trace{
+		Instrument: 106,
+		SampleRate: 100,
+		Start:      change.Add(-2 * time.Second),
+		Samples:    make([]int32, 500),
+	}
+
+	settings := Settings{
+		blksize:  512,
+		encoding: "int32",
+		verbose:  true,
+		mapping: Stations{
+			{Instrument: 106, End: change, Network: "NZ", Station: "LYLM", Components: []Component{{Channel: "EHZ", Dip: -90}}},
+			{Instrument: 106, Start: change, Network: "NZ", Station: "OTHR", Components: []Component{{Channel: "HHZ", Dip: -90}}},
+		},
+	}
+
+	var output records
+
+	conv := NewConverter(settings, &output)
+	if err := conv.Trace(trace); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for _, msr := range output {
+		station := msr.Station()
+		switch start, end := msr.StartTime(), msr.EndTime(); {
+		case station == "LYLM" && !end.Before(change):
+			t.Errorf("record after the mapping window: %v", end)
+		case station == "OTHR" && start.Before(change):
+			t.Errorf("record before the mapping window: %v", start)
+		}
+		counts[station] += msr.SampleCount()
+	}
+
+	if n := counts["LYLM"]; n != 200 {
+		t.Errorf("invalid first window sample count, expected %d but got %d", 200, n)
+	}
+	if n := counts["OTHR"]; n != 300 {
+		t.Errorf("invalid second window sample count, expected %d but got %d", 300, n)
+	}
+}
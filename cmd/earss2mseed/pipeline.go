@@ -0,0 +1,194 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ozym/earss/internal/earss"
+	"github.com/ozym/earss/internal/ms"
+)
+
+// Collector is an Output that keeps the records in memory.
+type Collector struct {
+	records []*ms.Record
+}
+
+// Write adds the record to the Collector.
+func (c *Collector) Write(msr *ms.Record) error {
+	c.records = append(c.records, msr)
+	return nil
+}
+
+// Close is a no-op as the records are kept in memory.
+func (c *Collector) Close() error {
+	return nil
+}
+
+// Records returns the records that have been written.
+func (c *Collector) Records() []*ms.Record {
+	return c.records
+}
+
+// counter is an io.Writer that counts the bytes written.
+type counter int64
+
+func (c *counter) Write(p []byte) (int, error) {
+	*c += counter(len(p))
+	return len(p), nil
+}
+
+// FileResult holds the outcome of converting a single input file.
+type FileResult struct {
+	Path    string
+	Size    int64
+	Hash    string
+	Buffers int
+	Skipped int
+	Records []*ms.Record
+	Err     error
+
+	// the assembly reports for the file, as these are not shared with any other file.
+	Tolerance       time.Duration
+	Discontinuities []earss.Discontinuity
+	GainChanges     []earss.GainChange
+}
+
+// ConvertFile decodes and packs a single EARSS file, the buffers are assembled independently of any other
+// file and the packed records are kept in memory. As a result traces, and any steim differences, restart at
+// the beginning of each file. Invalid buffers are skipped, whereas any other problem will stop the conversion
+// and is returned in the FileResult.
+func ConvertFile(settings Settings, path string) FileResult {
+	var output Collector
+
+	conv := NewConverter(settings, &output)
+	asm := settings.Assembler(conv.Trace)
+
+	res := convertFile(settings, path, asm.Add)
+	if res.Err != nil {
+		return res
+	}
+
+	if err := asm.Flush(); err != nil {
+		res.Err = err
+		return res
+	}
+
+	res.Records = output.Records()
+
+	res.Tolerance = asm.Tolerance()
+	res.Discontinuities = asm.Discontinuities()
+	res.GainChanges = asm.GainChanges()
+
+	return res
+}
+
+// convertFile reads the buffers from a single file and passes them to the given function, invalid buffers
+// are skipped. The size and checksum of the input are calculated while reading, and any other problem will
+// stop the conversion and is returned in the FileResult.
+func convertFile(settings Settings, path string, fn func(earss.Record) error) FileResult {
+	res := FileResult{
+		Path: path,
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer file.Close()
+
+	hash, size := sha256.New(), new(counter)
+
+	rd := earss.NewBuffers(io.TeeReader(file, io.MultiWriter(hash, size)), settings.resync)
+	defer reportSkipped(path, rd)
+
+	for res.Err == nil {
+		if !rd.Next() {
+			err := rd.Err()
+			if err == nil {
+				break
+			}
+			var herr *earss.HeaderError
+			if !errors.As(err, &herr) {
+				res.Err = err
+				break
+			}
+			log.Printf("skipping invalid buffer in %s: %v", path, err)
+			res.Skipped++
+			continue
+		}
+		res.Err = fn(rd.Record())
+	}
+
+	// the checksum is only of use if the whole input was read.
+	if res.Err == nil {
+		res.Size = int64(*size)
+		res.Hash = hex.EncodeToString(hash.Sum(nil))
+	}
+	res.Buffers = rd.Index()
+
+	return res
+}
+
+// Pipeline converts files using a pool of workers, the results are passed to the callback function in
+// the same order as the input files and from a single goroutine, so that output records are never
+// interleaved. To limit memory use only a small number of files are converted ahead of the next result.
+func Pipeline(settings Settings, paths []string, workers int, fn func(FileResult) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]chan FileResult, len(paths))
+	for i := range results {
+		results[i] = make(chan FileResult, 1)
+	}
+
+	// tokens limit how far the workers can get ahead of the callback.
+	tokens := make(chan struct{}, 2*workers)
+	done := make(chan struct{})
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range paths {
+			select {
+			case tokens <- struct{}{}:
+			case <-done:
+				return
+			}
+			jobs <- i
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] <- ConvertFile(settings, paths[i])
+			}
+		}()
+	}
+
+	var err error
+	for i := range paths {
+		res := <-results[i]
+		<-tokens
+
+		if err = fn(res); err != nil {
+			break
+		}
+	}
+
+	close(done)
+	wg.Wait()
+
+	return err
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozym/earss/internal/earss"
+)
+
+func TestPipeline_Order(t *testing.T) {
+	settings := Settings{blksize: 512, encoding: "steim2", timingquality: -1}
+
+	single := ConvertFile(settings, "../../internal/earss/testdata/lylm0313.dat")
+	if single.Err != nil {
+		t.Fatal(single.Err)
+	}
+
+	var paths []string
+	for i := 0; i < 10; i++ {
+		switch i {
+		case 3:
+			paths = append(paths, filepath.Join(t.TempDir(), "missing.dat"))
+		default:
+			paths = append(paths, "../../internal/earss/testdata/lylm0313.dat")
+		}
+	}
+
+	var results []FileResult
+	if err := Pipeline(settings, paths, 4, func(res FileResult) error {
+		results = append(results, res)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != len(paths) {
+		t.Fatalf("invalid number of results, expected %d got %d", len(paths), len(results))
+	}
+	for i, res := range results {
+		if res.Path != paths[i] {
+			t.Errorf("invalid result order, expected %s got %s", paths[i], res.Path)
+		}
+		switch i {
+		case 3:
+			if res.Err == nil {
+				t.Errorf("expected an error for a missing file")
+			}
+		default:
+			if res.Err != nil {
+				t.Errorf("unexpected error: %v", res.Err)
+			}
+			if len(res.Records) != len(single.Records) {
+				t.Errorf("invalid number of records, expected %d got %d", len(single.Records), len(res.Records))
+			}
+		}
+	}
+}
+
+func TestPipeline_ConvertFile(t *testing.T) {
+	settings := Settings{blksize: 512, encoding: "steim2", timingquality: -1}
+
+	// a callback error should stop the conversion and be returned.
+	failure := errors.New("write failure")
+
+	var count int
+	if res := convertFile(settings, "../../internal/earss/testdata/lylm0313.dat", func(earss.Record) error {
+		count++
+		return failure
+	}); !errors.Is(res.Err, failure) {
+		t.Errorf("invalid error, expected %v got %v", failure, res.Err)
+	}
+	if count != 1 {
+		t.Errorf("the conversion should stop after the first error, got %d calls", count)
+	}
+
+	// the per file results hold the same samples as a single assembly.
+	var samples int
+
+	conv := NewConverter(settings, &Collector{})
+	asm := settings.Assembler(func(trace earss.Trace) error {
+		samples += len(trace.Samples)
+		return conv.Trace(trace)
+	})
+	if res := convertFile(settings, "../../internal/earss/testdata/lylm0313.dat", asm.Add); res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	if err := asm.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	res := ConvertFile(settings, "../../internal/earss/testdata/lylm0313.dat")
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+
+	var packed int
+	for _, msr := range res.Records {
+		packed += msr.SampleCount()
+	}
+	if packed != samples {
+		t.Errorf("invalid number of samples, expected %d got %d", samples, packed)
+	}
+	if len(res.Discontinuities) != len(asm.Discontinuities()) || len(res.GainChanges) != len(asm.GainChanges()) {
+		t.Errorf("invalid assembly reports, expected %d and %d got %d and %d",
+			len(asm.Discontinuities()), len(asm.GainChanges()), len(res.Discontinuities), len(res.GainChanges))
+	}
+	if res.Tolerance != asm.Tolerance() {
+		t.Errorf("invalid tolerance, expected %v got %v", asm.Tolerance(), res.Tolerance)
+	}
+}
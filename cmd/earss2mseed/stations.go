@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Component describes the SEED channel code and orientation of a single recorder channel, the azimuth
+// is in degrees clockwise from north and the dip in degrees down from horizontal.
+type Component struct {
+	Channel string  `json:"channel"`
+	Azimuth float64 `json:"azimuth"`
+	Dip     float64 `json:"dip"`
+}
+
+// Station maps an EARSS instrument id onto SEED stream codes, an optional time window
+// allows the same instrument to be deployed at different sites. An optional timing quality,
+// as a percentage, can be given for instruments with a known clock reliability.
+//
+// An example mapping file entry would be:
+//
+//	[
+//	  {
+//	    "instrument": 106,
+//	    "start": "1994-01-01T00:00:00Z",
+//	    "end": "1995-01-01T00:00:00Z",
+//	    "network": "NZ",
+//	    "station": "LYLM",
+//	    "location": "10",
+//	    "timing_quality": 80,
+//	    "components": [
+//	      {"channel": "EHZ", "azimuth": 0, "dip": -90},
+//	      {"channel": "EHN", "azimuth": 0, "dip": 0},
+//	      {"channel": "EHE", "azimuth": 90, "dip": 0}
+//	    ]
+//	  }
+//	]
+type Station struct {
+	Instrument int       `json:"instrument"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+
+	Network  string `json:"network"`
+	Station  string `json:"station"`
+	Location string `json:"location"`
+
+	TimingQuality *int `json:"timing_quality,omitempty"`
+
+	Components []Component `json:"components"`
+}
+
+// Covers returns whether the Station applies at the given time, a zero start or end is open ended.
+func (s Station) Covers(at time.Time) bool {
+	if !s.Start.IsZero() && at.Before(s.Start) {
+		return false
+	}
+	if !s.End.IsZero() && !at.Before(s.End) {
+		return false
+	}
+	return true
+}
+
+// Component returns the channel details for the given recorder channel, if known.
+func (s Station) Component(offset int) (Component, bool) {
+	if offset < 0 || offset >= len(s.Components) {
+		return Component{}, false
+	}
+	return s.Components[offset], true
+}
+
+// Channel returns the SEED channel code for the given recorder channel, if known.
+func (s Station) Channel(offset int) (string, bool) {
+	c, ok := s.Component(offset)
+	return c.Channel, ok
+}
+
+// Stations holds the instrument mapping entries.
+type Stations []Station
+
+// ReadStations loads a JSON formatted instrument mapping file.
+func ReadStations(path string) (Stations, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var stations Stations
+	if err := json.Unmarshal(data, &stations); err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", path, err)
+	}
+
+	for i, s := range stations {
+		if !(s.Instrument > 0) {
+			return nil, fmt.Errorf("invalid instrument id in %s entry %d: %d", path, i+1, s.Instrument)
+		}
+		if !s.Start.IsZero() && !s.End.IsZero() && !s.End.After(s.Start) {
+			return nil, fmt.Errorf("invalid time window in %s entry %d: %s to %s", path, i+1,
+				s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339))
+		}
+		if q := s.TimingQuality; q != nil && (*q < 0 || *q > 100) {
+			return nil, fmt.Errorf("invalid timing quality in %s entry %d: %d", path, i+1, *q)
+		}
+		for j, c := range s.Components {
+			if c.Azimuth < 0 || c.Azimuth >= 360 {
+				return nil, fmt.Errorf("invalid azimuth in %s entry %d component %d: %g", path, i+1, j+1, c.Azimuth)
+			}
+			if c.Dip < -90 || c.Dip > 90 {
+				return nil, fmt.Errorf("invalid dip in %s entry %d component %d: %g", path, i+1, j+1, c.Dip)
+			}
+		}
+	}
+
+	return stations, nil
+}
+
+// Boundaries returns the start and end times of the instrument mapping windows that fall after the
+// from time and no later than the to time, in time order.
+func (s Stations) Boundaries(instrument int, from, to time.Time) []time.Time {
+	var times []time.Time
+	for _, v := range s {
+		if v.Instrument != instrument {
+			continue
+		}
+		for _, at := range []time.Time{v.Start, v.End} {
+			if at.IsZero() || !at.After(from) || at.After(to) {
+				continue
+			}
+			times = append(times, at)
+		}
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	return times
+}
+
+// Lookup returns the first Station that matches the instrument at the given time.
+func (s Stations) Lookup(instrument int, at time.Time) (Station, bool) {
+	for _, v := range s {
+		if v.Instrument != instrument || !v.Covers(at) {
+			continue
+		}
+		return v, true
+	}
+	return Station{}, false
+}
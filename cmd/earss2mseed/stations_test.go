@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStations_Read(t *testing.T) {
+
+	tests := map[string]struct {
+		data string
+		ok   bool
+	}{
+		"valid":          {data: `[{"instrument": 106, "network": "NZ", "station": "LYLM", "components": [{"channel": "EHZ"}]}]`, ok: true},
+		"open window":    {data: `[{"instrument": 106, "start": "1994-01-01T00:00:00Z"}]`, ok: true},
+		"bad json":       {data: `[{"instrument": 106,]`},
+		"bad instrument": {data: `[{"instrument": 0}]`},
+		"bad window":     {data: `[{"instrument": 106, "start": "1995-01-01T00:00:00Z", "end": "1994-01-01T00:00:00Z"}]`},
+		"bad quality":    {data: `[{"instrument": 106, "timing_quality": 101}]`},
+		"orientation":    {data: `[{"instrument": 106, "components": [{"channel": "EHE", "azimuth": 90, "dip": 0}]}]`, ok: true},
+		"bad azimuth":    {data: `[{"instrument": 106, "components": [{"channel": "EHE", "azimuth": 360}]}]`},
+		"bad dip":        {data: `[{"instrument": 106, "components": [{"channel": "EHZ", "dip": -91}]}]`},
+	}
+
+	for k, v := range tests {
+		t.Run(k, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "stations.json")
+			if err := os.WriteFile(path, []byte(v.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := ReadStations(path)
+			switch {
+			case v.ok && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case !v.ok && err == nil:
+				t.Errorf("expected an error")
+			}
+		})
+	}
+
+	if _, err := ReadStations(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestStations_Lookup(t *testing.T) {
+
+	change := time.Date(1994, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	stations := Stations{
+		{Instrument: 106, End: change, Network: "NZ", Station: "LYLM", Components: []Component{{Channel: "EHZ"}}},
+		{Instrument: 106, Start: change, Network: "NZ", Station: "OTHR", Components: []Component{{Channel: "HHZ"}}},
+		{Instrument: 107, Network: "NZ", Station: "ALWS"},
+	}
+
+	tests := map[string]struct {
+		instrument int
+		at         time.Time
+		station    string
+		ok         bool
+	}{
+		"first window":  {instrument: 106, at: change.Add(-time.Second), station: "LYLM", ok: true},
+		"second window": {instrument: 106, at: change, station: "OTHR", ok: true},
+		"open ended":    {instrument: 107, at: change, station: "ALWS", ok: true},
+		"unknown":       {instrument: 108, at: change},
+	}
+
+	for k, v := range tests {
+		t.Run(k, func(t *testing.T) {
+			station, ok := stations.Lookup(v.instrument, v.at)
+			if ok != v.ok {
+				t.Fatalf("invalid lookup, expected %v but got %v", v.ok, ok)
+			}
+			if station.Station != v.station {
+				t.Errorf("invalid station, expected %q but got %q", v.station, station.Station)
+			}
+		})
+	}
+
+	settings := Settings{
+		network:    "XX",
+		station:    "XXXX",
+		location:   "XX",
+		channel:    "EH",
+		components: "ZNE",
+		mapping:    stations,
+	}
+
+	codes := map[string]struct {
+		instrument int
+		offset     int
+		at         time.Time
+		codes      [4]string
+		ok         bool
+	}{
+		"mapped":          {instrument: 106, offset: 0, at: change, codes: [4]string{"NZ", "OTHR", "", "HHZ"}, ok: true},
+		"unknown":         {instrument: 108, offset: 1, at: change, codes: [4]string{"XX", "XXXX", "XX", "EHN"}},
+		"missing channel": {instrument: 106, offset: 2, at: change, codes: [4]string{"XX", "XXXX", "XX", "EHE"}},
+		"no components":   {instrument: 107, offset: 0, at: change, codes: [4]string{"XX", "XXXX", "XX", "EHZ"}},
+	}
+
+	for k, v := range codes {
+		t.Run(k, func(t *testing.T) {
+			network, station, location, channel, ok := settings.Codes(v.instrument, v.offset, v.at)
+			if ok != v.ok {
+				t.Errorf("invalid mapping flag, expected %v but got %v", v.ok, ok)
+			}
+			if c := [4]string{network, station, location, channel}; c != v.codes {
+				t.Errorf("invalid codes, expected %v but got %v", v.codes, c)
+			}
+		})
+	}
+}
+
+func TestStations_Boundaries(t *testing.T) {
+
+	change := time.Date(1994, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	stations := Stations{
+		{Instrument: 106, End: change, Station: "LYLM"},
+		{Instrument: 106, Start: change, Station: "OTHR"},
+		{Instrument: 107, Start: change.Add(time.Hour), Station: "ALWS"},
+	}
+
+	tests := map[string]struct {
+		instrument int
+		from, to   time.Time
+		count      int
+	}{
+		"crossing":    {instrument: 106, from: change.Add(-time.Minute), to: change.Add(time.Minute), count: 2},
+		"starting":    {instrument: 106, from: change, to: change.Add(time.Minute)},
+		"ending":      {instrument: 106, from: change.Add(-time.Minute), to: change, count: 2},
+		"before":      {instrument: 106, from: change.Add(-time.Hour), to: change.Add(-time.Minute)},
+		"other":       {instrument: 107, from: change, to: change.Add(2 * time.Hour), count: 1},
+		"no stations": {instrument: 108, from: change.Add(-time.Minute), to: change.Add(time.Minute)},
+	}
+
+	for k, v := range tests {
+		t.Run(k, func(t *testing.T) {
+			if n := len(stations.Boundaries(v.instrument, v.from, v.to)); n != v.count {
+				t.Errorf("invalid boundary count, expected %d but got %d", v.count, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ozym/earss/internal/earss"
+)
+
+type Settings struct {
+	verbose bool
+	format  string
+	resync  bool
+}
+
+// File describes the buffers read from a single input file.
+type File struct {
+	Path    string `json:"path"`
+	Buffers int    `json:"buffers"`
+	Invalid int    `json:"invalid"`
+	Skipped int64  `json:"skipped"`
+}
+
+// Report is the inventory of all the input files.
+type Report struct {
+	Files       []File          `json:"files"`
+	Instruments []earss.Summary `json:"instruments"`
+}
+
+func main() {
+
+	var settings Settings
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Summarise the instruments, timing and recording settings found in EARSS files\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "  %s [options] <files...>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Formats:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "  text  human readable listing of each file and instrument\n")
+		fmt.Fprintf(os.Stderr, "  json  the same inventory as a JSON document\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "General Options:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n")
+	}
+
+	flag.BoolVar(&settings.verbose, "verbose", false, "make noise.")
+	flag.StringVar(&settings.format, "format", "text", "output format, either text or json")
+	flag.BoolVar(&settings.resync, "resync", false, "search damaged or misaligned input files for buffers, reporting any skipped bytes")
+
+	flag.Parse()
+
+	switch settings.format {
+	case "text", "json":
+	default:
+		log.Fatalf("unknown format %q, expected text or json", settings.format)
+	}
+
+	var report Report
+
+	inv := earss.NewInventory()
+	for _, f := range flag.Args() {
+		if settings.verbose {
+			log.Printf("scanning file %s", f)
+		}
+		file, err := scanFile(settings, f, inv)
+		if err != nil {
+			log.Fatalf("unable to read %s: %v", f, err)
+		}
+		if settings.verbose {
+			log.Printf("read %d records from %s", file.Buffers, f)
+		}
+		report.Files = append(report.Files, file)
+	}
+	report.Instruments = inv.Summaries()
+
+	wr := bufio.NewWriter(os.Stdout)
+	defer wr.Flush()
+
+	switch settings.format {
+	case "json":
+		enc := json.NewEncoder(wr)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		if err := writeText(wr, report); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if settings.verbose {
+		log.Println("complete.")
+	}
+}
+
+// scanFile adds the buffers from a single file into the inventory, invalid buffers are counted and skipped.
+func scanFile(settings Settings, path string, inv *earss.Inventory) (File, error) {
+	res := File{
+		Path: path,
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return res, err
+	}
+	defer file.Close()
+
+	rd := earss.NewBuffers(file, settings.resync)
+	for {
+		if !rd.Next() {
+			err := rd.Err()
+			if err == nil {
+				break
+			}
+			var herr *earss.HeaderError
+			if !errors.As(err, &herr) {
+				return res, err
+			}
+			log.Printf("skipping invalid buffer in %s: %v", path, err)
+			res.Invalid++
+			continue
+		}
+		inv.Add(rd.Record())
+	}
+
+	res.Buffers = rd.Index() - res.Invalid
+	if sc, ok := rd.(*earss.Scanner); ok {
+		for _, s := range sc.Skipped() {
+			res.Skipped += s.Length
+		}
+	}
+
+	return res, nil
+}
+
+// distribution formats buffer counts as a list of values, each followed by its count in brackets.
+func distribution(counts map[int]int, unit string) string {
+	var keys []int
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%d%s (%d)", k, unit, counts[k]))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// writeText writes a human readable version of the inventory.
+func writeText(wr io.Writer, report Report) error {
+	tw := tabwriter.NewWriter(wr, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "File\tBuffers\tInvalid\tSkipped")
+	for _, f := range report.Files {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", f.Path, f.Buffers, f.Invalid, f.Skipped)
+	}
+
+	for _, s := range report.Instruments {
+		lo, hi := s.TimeCorrectionRange()
+
+		var tapes []string
+		for _, t := range s.Tapes {
+			tapes = append(tapes, fmt.Sprintf("%d", t))
+		}
+
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "Instrument %d\n", s.Instrument)
+		fmt.Fprintf(tw, "  Tapes:\t%s\n", strings.Join(tapes, ", "))
+		fmt.Fprintf(tw, "  Start:\t%s\n", s.Start.Format(time.RFC3339Nano))
+		fmt.Fprintf(tw, "  End:\t%s\n", s.End.Format(time.RFC3339Nano))
+		fmt.Fprintf(tw, "  Duration:\t%v\n", s.Duration())
+		fmt.Fprintf(tw, "  Buffers:\t%d\n", s.Buffers)
+		fmt.Fprintf(tw, "  Triggers:\t%d\n", s.Triggers)
+		fmt.Fprintf(tw, "  Sample rates:\t%s\n", distribution(s.SampleRates, " Hz"))
+		fmt.Fprintf(tw, "  Channels:\t%s\n", distribution(s.Channels, ""))
+		fmt.Fprintf(tw, "  Buffer types:\t%s\n", distribution(s.BufferTypes, ""))
+		fmt.Fprintf(tw, "  Time correction:\t%v to %v\n", lo, hi)
+		fmt.Fprintf(tw, "  Gains:\t\n")
+		for _, g := range s.Gains {
+			fmt.Fprintf(tw, "    %s\t%d %d %d\n", g.Time.Format(time.RFC3339Nano), g.System[0], g.System[1], g.System[2])
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ozym/earss/internal/earss"
+)
+
+func TestInfo_Scan(t *testing.T) {
+
+	data, err := os.ReadFile("../../internal/earss/testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same buffers following some leading garbage, which can only be found when resyncing.
+	damaged := filepath.Join(t.TempDir(), "damaged.dat")
+	if err := os.WriteFile(damaged, append(make([]byte, 100), data...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		path    string
+		resync  bool
+		buffers int
+		skipped int64
+		invalid int
+		err     bool
+	}{
+		"clean":     {path: "../../internal/earss/testdata/lylm0313.dat", buffers: 3},
+		"resync":    {path: damaged, resync: true, buffers: 3, skipped: 100},
+		"unaligned": {path: damaged, invalid: 3, err: true},
+	}
+
+	for k, v := range tests {
+		t.Run(k, func(t *testing.T) {
+			inv := earss.NewInventory()
+
+			file, err := scanFile(Settings{resync: v.resync}, v.path, inv)
+			switch {
+			case v.err && err == nil:
+				t.Fatal("expected an error for a short buffer")
+			case !v.err && err != nil:
+				t.Fatal(err)
+			}
+			if file.Invalid != v.invalid {
+				t.Errorf("invalid number of invalid buffers, expected %d got %d", v.invalid, file.Invalid)
+			}
+			if file.Buffers != v.buffers {
+				t.Errorf("invalid number of buffers, expected %d got %d", v.buffers, file.Buffers)
+			}
+			if file.Skipped != v.skipped {
+				t.Errorf("invalid number of skipped bytes, expected %d got %d", v.skipped, file.Skipped)
+			}
+			if n := len(inv.Summaries()); (n > 0) != (v.buffers > 0) {
+				t.Errorf("invalid number of instruments, got %d", n)
+			}
+		})
+	}
+
+	inv := earss.NewInventory()
+	file, err := scanFile(Settings{}, "../../internal/earss/testdata/lylm0313.dat", inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeText(&buf, Report{Files: []File{file}, Instruments: inv.Summaries()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the column widths are not checked.
+	lines := make(map[string]bool)
+	for _, l := range strings.Split(buf.String(), "\n") {
+		lines[strings.Join(strings.Fields(l), " ")] = true
+	}
+	for _, l := range []string{
+		"Instrument 106",
+		"Buffers: 3",
+		"Sample rates: 100 Hz (3)",
+		"Channels: 3 (3)",
+		"Time correction: 540ms to 540ms",
+		"1994-03-12T23:09:58.65Z 8 8 8",
+	} {
+		if !lines[l] {
+			t.Errorf("missing line %q in:\n%s", l, buf.String())
+		}
+	}
+
+	if _, err := scanFile(Settings{}, filepath.Join(t.TempDir(), "missing.dat"), earss.NewInventory()); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
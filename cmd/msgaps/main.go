@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ozym/earss/internal/ms"
+)
+
+// TimeFormat is used to display the sample times.
+const TimeFormat = "2006,002,15:04:05.000000"
+
+type Settings struct {
+	verbose   bool
+	tolerance time.Duration
+	overlaps  bool
+}
+
+// readRecords loads the headers of all the miniseed records in a file, the sample data is not kept.
+func readRecords(path string) ([]ms.Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return decodeRecords(file)
+}
+
+func decodeRecords(rd io.Reader) ([]ms.Record, error) {
+	var records []ms.Record
+
+	reader := ms.NewReader(rd)
+	for reader.Next() {
+		msr := *reader.Record()
+		msr.Data = nil
+		records = append(records, msr)
+	}
+	if err := reader.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func main() {
+
+	var settings Settings
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Report gaps and overlaps in miniSeed files\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "  %s [options] <files...>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "General Options:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n")
+	}
+
+	flag.BoolVar(&settings.verbose, "verbose", false, "make noise.")
+	flag.DurationVar(&settings.tolerance, "tolerance", 0, "time tolerance before reporting a gap or overlap, zero will use half the sample period")
+	flag.BoolVar(&settings.overlaps, "overlaps", true, "report overlaps as well as gaps")
+
+	flag.Parse()
+
+	var records []ms.Record
+	switch files := flag.Args(); {
+	case len(files) == 0:
+		list, err := decodeRecords(os.Stdin)
+		if err != nil {
+			log.Fatalf("unable to read stdin: %v", err)
+		}
+		records = append(records, list...)
+	default:
+		for _, f := range files {
+			list, err := readRecords(f)
+			if err != nil {
+				log.Fatalf("unable to read %s: %v", f, err)
+			}
+			if settings.verbose {
+				log.Printf("read %d records from %s", len(list), f)
+			}
+			records = append(records, list...)
+		}
+	}
+
+	if err := writeGaps(os.Stdout, settings, records); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeGaps writes a line for each gap, or overlap if requested, followed by the totals.
+func writeGaps(out io.Writer, settings Settings, records []ms.Record) error {
+	var gaps, overlaps int
+
+	wr := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(wr, "Source\tLast Sample\tNext Sample\tGap\tSamples")
+	for _, g := range ms.FindGaps(records, settings.tolerance) {
+		switch {
+		case g.IsOverlap() && !settings.overlaps:
+			continue
+		case g.IsOverlap():
+			overlaps++
+		default:
+			gaps++
+		}
+		fmt.Fprintf(wr, "%s\t%s\t%s\t%v\t%d\n", g.SrcName, g.End.Format(TimeFormat), g.Start.Format(TimeFormat), g.Duration(), g.Samples())
+	}
+	if err := wr.Flush(); err != nil {
+		return err
+	}
+
+	var err error
+	switch settings.overlaps {
+	case true:
+		_, err = fmt.Fprintf(out, "Total: %d gap(s), %d overlap(s)\n", gaps, overlaps)
+	default:
+		_, err = fmt.Fprintf(out, "Total: %d gap(s)\n", gaps)
+	}
+
+	return err
+}
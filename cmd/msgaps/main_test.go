@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ozym/earss/internal/ms"
+)
+
+func TestGaps_Report(t *testing.T) {
+
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	rec := ms.NewEmptyRecord(9, 100, 1)
+	rec.SetNetwork("NZ")
+	rec.SetStation("WEL")
+	rec.SetLocation("10")
+	rec.SetChannel("HHZ")
+
+	// there is a gap after the first block of samples, and the third block overlaps the second.
+	var records []*ms.Record
+	for _, at := range []time.Time{start, start.Add(20 * time.Second), start.Add(29500 * time.Millisecond)} {
+		if err := rec.PackInt32(at, make([]int32, 1000), func(msr *ms.Record) error {
+			records = append(records, msr)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "gaps.mseed")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msr := range records {
+		if err := msr.Encode(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := readRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(records) {
+		t.Fatalf("invalid number of records, expected %d got %d", len(records), len(list))
+	}
+
+	tests := map[string]struct {
+		overlaps bool
+		lines    int
+		total    string
+	}{
+		"gaps":     {lines: 3, total: "Total: 1 gap(s)"},
+		"overlaps": {overlaps: true, lines: 4, total: "Total: 1 gap(s), 1 overlap(s)"},
+	}
+
+	for k, v := range tests {
+		t.Run(k, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeGaps(&buf, Settings{overlaps: v.overlaps}, list); err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if len(lines) != v.lines {
+				t.Fatalf("invalid number of lines, expected %d got %d: %q", v.lines, len(lines), lines)
+			}
+			if !strings.HasPrefix(lines[1], "NZ_WEL_10_HHZ") {
+				t.Errorf("invalid gap source, got %q", lines[1])
+			}
+			if l := lines[len(lines)-1]; l != v.total {
+				t.Errorf("invalid totals, expected %q got %q", v.total, l)
+			}
+		})
+	}
+
+	if _, err := readRecords(filepath.Join(t.TempDir(), "missing.mseed")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/ozym/earss/internal/ms"
+)
+
+type Settings struct {
+	verbose bool
+	sort    bool
+	strict  bool
+	output  string
+}
+
+// readRecords appends the miniseed records found in a file.
+func readRecords(path string, fn ms.RecordFunc) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var count int
+
+	reader := ms.NewReader(file)
+	for reader.Next() {
+		if err := fn(reader.Record()); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := reader.Err(); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// merge writes the records from the files that have not been seen before, the returned Dedup holds the
+// number of repeated records and any conflicts.
+func merge(settings Settings, paths []string, wr io.Writer) (*ms.Dedup, error) {
+	var records []ms.Record
+	write := func(msr *ms.Record) error {
+		if settings.sort {
+			records = append(records, *msr)
+			return nil
+		}
+		return msr.Encode(wr)
+	}
+
+	dedup := ms.NewDedup()
+	filter := dedup.Filter(write)
+
+	for _, f := range paths {
+		n, err := readRecords(f, filter)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", f, err)
+		}
+		if settings.verbose {
+			log.Printf("read %d records from %s", n, f)
+		}
+	}
+
+	if settings.sort {
+		sort.SliceStable(records, func(i, j int) bool {
+			return records[i].RecordHeader.Less(records[j].RecordHeader)
+		})
+		for _, msr := range records {
+			if err := msr.Encode(wr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return dedup, nil
+}
+
+func main() {
+
+	var settings Settings
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Merge miniSeed files, dropping any repeated records\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "  %s [options] <files...>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "General Options:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n")
+	}
+
+	flag.BoolVar(&settings.verbose, "verbose", false, "make noise.")
+	flag.BoolVar(&settings.sort, "sort", false, "sort the merged records by stream and start time")
+	flag.BoolVar(&settings.strict, "strict", false, "exit with an error if any conflicting records are found")
+	flag.StringVar(&settings.output, "output", "", "output file, otherwise write to stdout")
+
+	flag.Parse()
+
+	// the output file is closed explicitly so that any final write error is reported.
+	var out io.WriteCloser = os.Stdout
+	if settings.output != "" {
+		file, err := os.Create(settings.output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out = file
+	}
+
+	wr := bufio.NewWriter(out)
+
+	dedup, err := merge(settings, flag.Args(), wr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := wr.Flush(); err != nil {
+		log.Fatalf("unable to write output: %v", err)
+	}
+	if settings.output != "" {
+		if err := out.Close(); err != nil {
+			log.Fatalf("unable to close %s: %v", settings.output, err)
+		}
+	}
+
+	for _, c := range dedup.Conflicts() {
+		log.Printf("conflicting record dropped: %s", c)
+	}
+
+	if settings.verbose {
+		log.Printf("dropped %d repeated records, %d with conflicting data", dedup.Dropped(), len(dedup.Conflicts()))
+	}
+
+	if settings.strict && len(dedup.Conflicts()) > 0 {
+		log.Fatalf("found %d conflicting records", len(dedup.Conflicts()))
+	}
+}
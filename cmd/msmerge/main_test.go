@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ozym/earss/internal/ms"
+)
+
+// writeRecords packs the samples of a stream and writes the records into a file.
+func writeRecords(t *testing.T, path, station string, start time.Time, samples []int32) {
+	rec := ms.NewEmptyRecord(9, 100, 1)
+	rec.SetNetwork("NZ")
+	rec.SetStation(station)
+	rec.SetChannel("HHZ")
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.PackInt32(start, samples, func(msr *ms.Record) error {
+		return msr.Encode(file)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	dir := t.TempDir()
+
+	// the second file repeats the first, with a different sample in its last record, then adds another stream.
+	first, second := filepath.Join(dir, "first.mseed"), filepath.Join(dir, "second.mseed")
+
+	samples := make([]int32, 500)
+	writeRecords(t, first, "WEL", start, samples)
+
+	changed := make([]int32, 500)
+	changed[499] = 1
+	writeRecords(t, second, "WEL", start, changed)
+	writeRecords(t, second, "TDHS", start, samples)
+
+	tests := map[string]struct {
+		sort  bool
+		first string
+	}{
+		"unsorted": {first: "WEL"},
+		"sorted":   {sort: true, first: "TDHS"},
+	}
+
+	for k, v := range tests {
+		t.Run(k, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			dedup, err := merge(Settings{sort: v.sort}, []string{first, second}, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var records []ms.Record
+			reader := ms.NewReader(&buf)
+			for reader.Next() {
+				records = append(records, *reader.Record())
+			}
+			if err := reader.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			if n := dedup.Dropped(); n != len(records)/2 {
+				t.Errorf("invalid number of dropped records, expected %d got %d", len(records)/2, n)
+			}
+			if n := len(dedup.Conflicts()); n != 1 {
+				t.Errorf("invalid number of conflicts, expected %d got %d", 1, n)
+			}
+			if len(records) == 0 || records[0].Station() != v.first {
+				t.Fatalf("invalid first record, expected station %q", v.first)
+			}
+			for i := 1; i < len(records) && v.sort; i++ {
+				if records[i].RecordHeader.Less(records[i-1].RecordHeader) {
+					t.Errorf("invalid record order at %d", i)
+				}
+			}
+		})
+	}
+
+	if _, err := merge(Settings{}, []string{filepath.Join(dir, "missing.mseed")}, &bytes.Buffer{}); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
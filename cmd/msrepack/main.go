@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ozym/earss/internal/ms"
+)
+
+type Settings struct {
+	verbose   bool
+	blksize   int
+	encoding  string
+	tolerance time.Duration
+	output    string
+
+	network  string
+	station  string
+	location string
+	channel  string
+	quality  string
+	start    string
+	end      string
+}
+
+func (s Settings) Blksize() int {
+	return int(math.Log(float64(s.blksize)) / math.Log(2))
+}
+
+// Encoding returns the miniseed encoding for the encoding setting.
+func (s Settings) Encoding() (ms.Encoding, bool) {
+	switch s.encoding {
+	case "int32":
+		return ms.EncodingInt32, true
+	case "steim1":
+		return ms.EncodingSTEIM1, true
+	case "steim2":
+		return ms.EncodingSTEIM2, true
+	case "float32":
+		return ms.EncodingIEEEFloat, true
+	case "float64":
+		return ms.EncodingIEEEDouble, true
+	default:
+		return 0, false
+	}
+}
+
+// Selection returns the record selection for the stream, quality and time window settings.
+func (s Settings) Selection() (ms.Selection, error) {
+	sel := ms.Selection{
+		Network:  s.network,
+		Station:  s.station,
+		Location: s.location,
+		Channel:  s.channel,
+		Quality:  s.quality,
+	}
+
+	if s.start != "" {
+		t, err := time.Parse(time.RFC3339Nano, s.start)
+		if err != nil {
+			return ms.Selection{}, fmt.Errorf("invalid start time %q: %w", s.start, err)
+		}
+		sel.Start = t
+	}
+	if s.end != "" {
+		t, err := time.Parse(time.RFC3339Nano, s.end)
+		if err != nil {
+			return ms.Selection{}, fmt.Errorf("invalid end time %q: %w", s.end, err)
+		}
+		sel.End = t
+	}
+
+	return sel, nil
+}
+
+// readRecords passes the selected miniseed records from a file to the callback, records are trimmed to the
+// selection time window.
+func readRecords(path string, sel ms.Selection, fn ms.RecordFunc) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := ms.NewReader(file)
+	for reader.Next() {
+		msr := reader.Record()
+		if !sel.Match(*msr) {
+			continue
+		}
+		if err := sel.Trim(msr, fn); err != nil {
+			return err
+		}
+	}
+
+	return reader.Err()
+}
+
+// sources returns the stream names, including the quality code, of the selected records found in the files,
+// these are in the order used by RecordHeader.Less.
+func sources(paths []string, sel ms.Selection) ([]string, error) {
+	headers := make(map[string]ms.RecordHeader)
+	for _, f := range paths {
+		if err := readRecords(f, sel, func(msr *ms.Record) error {
+			if _, ok := headers[msr.SrcName(true)]; !ok {
+				headers[msr.SrcName(true)] = msr.RecordHeader
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", f, err)
+		}
+	}
+
+	var names []string
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return headers[names[i]].Less(headers[names[j]])
+	})
+
+	return names, nil
+}
+
+// repack joins the selected records of each stream in turn, only the records of a single stream are held
+// in memory at any one time. The number of records read is returned.
+func repack(repacker *ms.Repacker, paths []string, sel ms.Selection, fn ms.RecordFunc) (int, error) {
+	names, err := sources(paths, sel)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, name := range names {
+		var records []ms.Record
+		for _, f := range paths {
+			if err := readRecords(f, sel, func(msr *ms.Record) error {
+				if msr.SrcName(true) == name {
+					records = append(records, *msr)
+				}
+				return nil
+			}); err != nil {
+				return count, fmt.Errorf("unable to read %s: %w", f, err)
+			}
+		}
+		if err := repacker.Repack(records, fn); err != nil {
+			return count, err
+		}
+		count += len(records)
+	}
+
+	return count, nil
+}
+
+func main() {
+
+	var settings Settings
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Repack fragmented miniSeed records into full records\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "  %s [options] <files...>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "General Options:\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n")
+	}
+
+	flag.BoolVar(&settings.verbose, "verbose", false, "make noise.")
+	flag.IntVar(&settings.blksize, "blksize", 4096, "miniseed block size in bytes")
+	flag.StringVar(&settings.encoding, "encoding", "steim2", "miniseed data encoding, one of int32, steim1, steim2, float32 or float64")
+	flag.DurationVar(&settings.tolerance, "tolerance", 0, "time tolerance when joining records, zero will use half the sample period")
+	flag.StringVar(&settings.output, "output", "", "output file, otherwise write to stdout")
+	flag.StringVar(&settings.network, "network", "", "select network codes matching the glob pattern")
+	flag.StringVar(&settings.station, "station", "", "select station codes matching the glob pattern")
+	flag.StringVar(&settings.location, "location", "", "select location codes matching the glob pattern")
+	flag.StringVar(&settings.channel, "channel", "", "select channel codes matching the glob pattern")
+	flag.StringVar(&settings.quality, "quality", "", "select records with one of the given quality codes, e.g. DR")
+	flag.StringVar(&settings.start, "start", "", "select samples at or after the given RFC3339 time")
+	flag.StringVar(&settings.end, "end", "", "select samples before the given RFC3339 time")
+
+	flag.Parse()
+
+	encoding, ok := settings.Encoding()
+	if !ok {
+		log.Fatalf("unknown encoding %q, expected int32, steim1, steim2, float32 or float64", settings.encoding)
+	}
+	if settings.blksize <= 0 || 1<<settings.Blksize() != settings.blksize {
+		log.Fatalf("invalid block size %d, expected a power of two", settings.blksize)
+	}
+
+	sel, err := settings.Selection()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repacker, err := ms.NewRepacker(settings.Blksize(), encoding, settings.tolerance)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the output file is closed explicitly so that any final write error is reported.
+	var out io.WriteCloser = os.Stdout
+	if settings.output != "" {
+		file, err := os.Create(settings.output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out = file
+	}
+
+	wr := bufio.NewWriter(out)
+	count, err := repack(repacker, flag.Args(), sel, func(msr *ms.Record) error {
+		return msr.Encode(wr)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := wr.Flush(); err != nil {
+		log.Fatalf("unable to write output: %v", err)
+	}
+	if settings.output != "" {
+		if err := out.Close(); err != nil {
+			log.Fatalf("unable to close %s: %v", settings.output, err)
+		}
+	}
+
+	if settings.verbose {
+		log.Printf("repacked %d records into %d", count, repacker.Count())
+	}
+}
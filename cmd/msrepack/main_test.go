@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ozym/earss/internal/ms"
+)
+
+// fragments packs the samples of a stream into small records and writes them into two files.
+func fragments(t *testing.T, dir, station string, start time.Time, samples []int32) []string {
+	rec := ms.NewEmptyRecord(7, 100, 1)
+	rec.SetNetwork("NZ")
+	rec.SetStation(station)
+	rec.SetChannel("HHZ")
+
+	var records []*ms.Record
+	if err := rec.PackInt32(start, samples, func(msr *ms.Record) error {
+		records = append(records, msr)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for i, list := range [][]*ms.Record{records[:len(records)/2], records[len(records)/2:]} {
+		path := filepath.Join(dir, station+"."+string(rune('a'+i))+".mseed")
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, msr := range list {
+			if err := msr.Encode(file); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+func TestRepack(t *testing.T) {
+
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	samples := make([]int32, 1000)
+	for i := range samples {
+		samples[i] = int32(i%50 - 25)
+	}
+
+	dir := t.TempDir()
+
+	// the files of the two streams are interleaved, each stream is split across two files.
+	wel, tdhs := fragments(t, dir, "WEL", start, samples), fragments(t, dir, "TDHS", start, samples)
+	paths := []string{wel[0], tdhs[0], wel[1], tdhs[1]}
+
+	repacker, err := ms.NewRepacker(12, ms.EncodingSTEIM2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stations []string
+	values := make(map[string][]int32)
+
+	count, err := repack(repacker, paths, ms.Selection{}, func(msr *ms.Record) error {
+		list, err := msr.Int32s()
+		if err != nil {
+			return err
+		}
+		stations = append(stations, msr.Station())
+		values[msr.Station()] = append(values[msr.Station()], list...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count <= len(stations) {
+		t.Errorf("invalid number of records read, expected more than %d but got %d", len(stations), count)
+	}
+	if !reflect.DeepEqual(stations, []string{"TDHS", "WEL"}) {
+		t.Errorf("invalid repacked records, expected one record per stream in order but got %v", stations)
+	}
+	for k, v := range values {
+		if !reflect.DeepEqual(v, samples) {
+			t.Errorf("invalid %s samples after repacking", k)
+		}
+	}
+
+	// a selection only repacks the matching stream.
+	repacker, err = ms.NewRepacker(12, ms.EncodingSTEIM2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repack(repacker, paths, ms.Selection{Station: "WEL"}, func(msr *ms.Record) error {
+		if s := msr.Station(); s != "WEL" {
+			t.Errorf("invalid station, expected %q but got %q", "WEL", s)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n := repacker.Count(); n != 1 {
+		t.Errorf("invalid number of repacked records, expected %d but got %d", 1, n)
+	}
+
+	if _, err := repack(repacker, []string{filepath.Join(dir, "missing.mseed")}, ms.Selection{}, func(*ms.Record) error {
+		return nil
+	}); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
@@ -1,7 +1,9 @@
 package earss
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
 	"strings"
@@ -16,12 +18,25 @@ const (
 	DataValues   = 8184
 )
 
+// TimeCorrectionUnit is the resolution of the header time correction field. The field is a signed
+// 16 bit count of these units which, following the SEED convention, is added to the header time
+// to give the corrected time, e.g. a value of 54 is a correction of +0.54 seconds.
+const TimeCorrectionUnit = 10 * time.Millisecond
+
 // GainSample accounts for the gain ranging
 var GainSample = [8]int{128, 64, 32, 16, 8, 4, 2, 1}
 
 // GainSystem accounts for the channel gain setting
 var GainSystem = [8]int{1, 2, 4, 8, 16, 32, 64, 128}
 
+// systemGain returns the channel gain multiplier for a gain setting.
+func systemGain(gain int) int {
+	if gain < 0 || gain >= len(GainSystem) {
+		return 1
+	}
+	return GainSystem[gain]
+}
+
 func decodeSample(value int16) int {
 	mag := int(value&4095) * GainSample[int((value>>12)&7)]
 	if value < 0 {
@@ -40,11 +55,70 @@ type Record struct {
 	BufferNumber     int
 	LastTrigger      bool
 	SampleRate       int
-	TimeCorrection   int
+	TimeCorrection   int // in TimeCorrectionUnit (10 ms) units
 	Gain             [MaxChannels]int
 	Samples          [DataValues]int
 }
 
+// Start returns the time of the first sample in the buffer, this will be before
+// the header StartTime if any pre-event data has been included.
+func (r Record) Start() time.Time {
+	return r.StartTime.Add(-time.Second * time.Duration(r.PreEventSeconds))
+}
+
+// Correction returns the header time correction.
+func (r Record) Correction() time.Duration {
+	return time.Duration(r.TimeCorrection) * TimeCorrectionUnit
+}
+
+// SamplePeriod returns the nominal time between samples.
+func (r Record) SamplePeriod() time.Duration {
+	if r.SampleRate > 0 {
+		return time.Second / time.Duration(r.SampleRate)
+	}
+	return 0
+}
+
+// SampleCount returns the number of samples per channel in the buffer.
+func (r Record) SampleCount() int {
+	if r.NumberOfChannels > 0 {
+		return DataValues / r.NumberOfChannels
+	}
+	return 0
+}
+
+// EndTime returns the time of the last sample in the buffer.
+func (r Record) EndTime() time.Time {
+	return r.Start().Add(time.Duration(r.SampleCount()-1) * r.SamplePeriod())
+}
+
+// Channel returns the samples for a single channel, or nil if the channel is not present.
+func (r Record) Channel(channel int) []int32 {
+	if channel < 0 || channel >= r.NumberOfChannels {
+		return nil
+	}
+	samples := make([]int32, 0, r.SampleCount())
+	for i := channel; i < DataValues; i += r.NumberOfChannels {
+		samples = append(samples, int32(r.Samples[i]))
+	}
+	return samples
+}
+
+// Calibrated returns the samples for a single channel normalised by the channel system gain,
+// or nil if the channel is not present.
+func (r Record) Calibrated(channel int) []float64 {
+	if channel < 0 || channel >= r.NumberOfChannels {
+		return nil
+	}
+	gain := float64(systemGain(r.Gain[channel]))
+
+	samples := make([]float64, 0, r.SampleCount())
+	for i := channel; i < DataValues; i += r.NumberOfChannels {
+		samples = append(samples, float64(r.Samples[i])/gain)
+	}
+	return samples
+}
+
 func (r Record) String() string {
 	var sb strings.Builder
 	sb.WriteString(r.StartTime.Format(time.RFC3339Nano))
@@ -89,20 +163,19 @@ func (r Record) Header() string {
 	return sb.String()
 }
 
+// Decode fills the Record from a single EARSS buffer, the header is checked before
+// decoding and any inconsistencies are returned as a HeaderError.
 func (r *Record) Decode(data []byte) error {
 	if len(data) != BufferLength {
-		return fmt.Errorf("invalid length")
+		return fmt.Errorf("%w: %d", ErrBadLength, len(data))
+	}
+	if err := checkHeader(data); err != nil {
+		return err
 	}
 
 	header := data[BufferLength-HeaderLength:]
 
-	year := int(header[5])
-	switch {
-	case year < 50:
-		year += 2000
-	default:
-		year += 1900
-	}
+	year := decodeYear(header[5])
 	month := int(header[6])
 	day := int(header[7])
 	hour := int(header[10])
@@ -138,14 +211,30 @@ func (r *Record) Decode(data []byte) error {
 	return nil
 }
 
+// Decode converts a byte slice of EARSS buffers into a Record slice. Buffers with invalid headers are
+// skipped and a trailing partial buffer is reported as a BufferError wrapping ErrShortBuffer, in either
+// case the Records that could be decoded are returned along with the errors.
 func Decode(data []byte) ([]Record, error) {
 	var records []Record
-	for i := 0; i < len(data)/BufferLength; i++ {
-		var record Record
-		if err := record.Decode(data[i*BufferLength : (i+1)*BufferLength]); err != nil {
-			return nil, err
+	var errs []error
+
+	rd := NewReader(bytes.NewReader(data))
+	for {
+		if !rd.Next() {
+			err := rd.Err()
+			if err == nil {
+				break
+			}
+			errs = append(errs, err)
+
+			var herr *HeaderError
+			if !errors.As(err, &herr) {
+				break
+			}
+			continue
 		}
-		records = append(records, record)
+		records = append(records, rd.Record())
 	}
-	return records, nil
+
+	return records, errors.Join(errs...)
 }
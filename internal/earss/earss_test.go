@@ -3,6 +3,7 @@ package earss
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestEarss_Reading(t *testing.T) {
@@ -29,6 +30,9 @@ func TestEarss_Reading(t *testing.T) {
 		if s := record.TimeCorrection; s != 54 {
 			t.Errorf("invalid time correction for record %d, expected %d but got %d", i+1, 54, s)
 		}
+		if s := record.Correction(); s != 540*time.Millisecond {
+			t.Errorf("invalid time correction for record %d, expected %v but got %v", i+1, 540*time.Millisecond, s)
+		}
 		if s := record.Samples[0]; s != first[i] {
 			t.Errorf("invalid first sample for record %d, expected %d but got %d", i+1, first[i], s)
 		}
@@ -37,3 +41,29 @@ func TestEarss_Reading(t *testing.T) {
 		}
 	}
 }
+
+func TestEarss_Calibrated(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var record Record
+	if err := record.Decode(data[:BufferLength]); err != nil {
+		t.Fatal(err)
+	}
+
+	for channel := 0; channel < record.NumberOfChannels; channel++ {
+		raw, samples := record.Channel(channel), record.Calibrated(channel)
+		if len(raw) != len(samples) {
+			t.Fatalf("invalid calibrated sample count, expected %d but got %d", len(raw), len(samples))
+		}
+		gain := float64(GainSystem[record.Gain[channel]])
+		for i := range raw {
+			if v := float64(raw[i]) / gain; samples[i] != v {
+				t.Fatalf("invalid calibrated sample %d, expected %g but got %g", i, v, samples[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package earss
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrBadSample is returned when a sample is too large to be gain ranged.
+var ErrBadSample = errors.New("sample out of range")
+
+// MaxSample is the largest magnitude that can be represented by a gain ranged sample.
+const MaxSample = 4095 * 128
+
+// encodeSample converts a sample into a gain ranged value, using the smallest
+// gain that can represent the magnitude in a 12 bit mantissa. Values that are not
+// a multiple of the chosen gain are rounded to the nearest representable value.
+func encodeSample(value int) (int16, error) {
+	mag := value
+	if mag < 0 {
+		mag = -mag
+	}
+	if mag > MaxSample {
+		return 0, fmt.Errorf("%w: %d", ErrBadSample, value)
+	}
+
+	for i := len(GainSample) - 1; i >= 0; i-- {
+		gain := GainSample[i]
+
+		mantissa := (mag + gain/2) / gain
+		if mantissa > 4095 {
+			continue
+		}
+
+		res := uint16(i<<12) | uint16(mantissa)
+		if value < 0 {
+			res |= 0x8000
+		}
+
+		return int16(res), nil
+	}
+
+	return 0, fmt.Errorf("%w: %d", ErrBadSample, value)
+}
+
+// encodeRate converts a sample rate into the two header rate bits.
+func encodeRate(rate int) byte {
+	var bits byte
+	for r := 25; r < rate && bits < 3; r *= 2 {
+		bits++
+	}
+	return bits
+}
+
+// Marshal converts a Record into an EARSS formatted buffer, it is the inverse of Decode.
+func (r Record) Marshal() ([]byte, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, BufferLength)
+
+	for i, v := range r.Samples {
+		value, err := encodeSample(v)
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %w", i, err)
+		}
+		binary.LittleEndian.PutUint16(data[i*2:(i+1)*2], uint16(value))
+	}
+
+	header := data[BufferLength-HeaderLength:]
+
+	header[0] = byte(r.BufferType&15) | encodeRate(r.SampleRate)<<4
+	if r.LastTrigger {
+		header[0] |= 128
+	}
+	header[1] = byte(r.BufferNumber - 1)
+	header[2] = byte(r.NumberOfChannels-1)&3 | byte(r.Gain[0]&7)<<4 | byte(r.Gain[1]&1)<<7
+	header[3] = byte(r.Gain[1]>>1)&3 | byte(r.Gain[2]&7)<<2
+	header[4] = byte(r.PreEventSeconds)
+
+	header[5] = byte(r.StartTime.Year() % 100)
+	header[6] = byte(r.StartTime.Month())
+	header[7] = byte(r.StartTime.Day())
+
+	binary.LittleEndian.PutUint16(header[8:10], uint16(int16(r.TimeCorrection)))
+
+	header[10] = byte(r.StartTime.Hour())
+	header[11] = byte(r.StartTime.Minute())
+	header[12] = byte(r.StartTime.Second())
+	header[13] = byte(r.StartTime.Nanosecond() / 10000000)
+	header[14] = byte(r.Instrument)
+	header[15] = byte(r.TapeNumber)
+
+	return data, nil
+}
+
+// Encode writes an EARSS formatted buffer into the given Writer.
+func (r Record) Encode(wr io.Writer) error {
+	data, err := r.Marshal()
+	if err != nil {
+		return err
+	}
+	if _, err := wr.Write(data); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Encode converts a Record slice into a byte slice of EARSS buffers.
+func Encode(records []Record) ([]byte, error) {
+	data := make([]byte, 0, len(records)*BufferLength)
+	for i, r := range records {
+		buf, err := r.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		data = append(data, buf...)
+	}
+	return data, nil
+}
@@ -0,0 +1,95 @@
+package earss
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestEncode_Sample(t *testing.T) {
+
+	for _, v := range []int{0, 1, -1, 4095, -4095, 4096, 8190, -8190, 3001 * 32, MaxSample, -MaxSample} {
+		t.Run(fmt.Sprintf("sample %d", v), func(t *testing.T) {
+			value, err := encodeSample(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := decodeSample(value); s != v {
+				t.Errorf("invalid sample, expected %d but got %d", v, s)
+			}
+		})
+	}
+
+	if _, err := encodeSample(MaxSample + 1); !errors.Is(err, ErrBadSample) {
+		t.Errorf("expected error %v, got %v", ErrBadSample, err)
+	}
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, record := range records {
+		buf, err := record.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		raw := data[i*BufferLength : (i+1)*BufferLength]
+		if !bytes.Equal(buf[DataLength:], raw[DataLength:]) {
+			t.Errorf("invalid header for record %d, expected %x but got %x", i+1, raw[DataLength:], buf[DataLength:])
+		}
+
+		// the recorder does not always use the smallest gain, so compare the sample values.
+		for j := 0; j < DataLength; j += 2 {
+			v, s := int16(binary.LittleEndian.Uint16(raw[j:])), int16(binary.LittleEndian.Uint16(buf[j:]))
+			if decodeSample(v) != decodeSample(s) {
+				t.Fatalf("invalid sample at offset %d for record %d, expected %d but got %d", j, i+1, decodeSample(v), decodeSample(s))
+			}
+		}
+
+		var res Record
+		if err := res.Decode(buf); err != nil {
+			t.Fatal(err)
+		}
+		if res != record {
+			t.Errorf("invalid record %d, decoded record does not match original", i+1)
+		}
+
+		again, err := res.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again, buf) {
+			t.Errorf("invalid buffer for record %d, encoding is not stable", i+1)
+		}
+	}
+
+	buf, err := Encode(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Decode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != len(records) {
+		t.Fatalf("invalid record count, expected %d but got %d", len(records), len(res))
+	}
+	for i := range res {
+		if res[i] != records[i] {
+			t.Errorf("invalid record %d, decoded record does not match original", i+1)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package earss
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event is a single triggered recording from an instrument, it holds the buffers from the first
+// buffer of the recording through to the buffer flagged as the LastTrigger.
+type Event struct {
+	Instrument       int
+	TapeNumber       int
+	BufferType       int
+	SampleRate       int
+	NumberOfChannels int
+	PreEventSeconds  int
+
+	// Start is the time of the first sample, including any pre-event data.
+	Start time.Time
+	// Trigger is the header time of the first buffer, the pre-event data is recorded before this.
+	Trigger time.Time
+
+	// Complete indicates the event was closed by a buffer flagged as the LastTrigger, otherwise
+	// the recording was interrupted or the stream ended before the event was finished.
+	Complete bool
+
+	Buffers []Record
+}
+
+// newEvent returns an Event that starts with the given buffer.
+func newEvent(record Record) *Event {
+	return &Event{
+		Instrument:       record.Instrument,
+		TapeNumber:       record.TapeNumber,
+		BufferType:       record.BufferType,
+		SampleRate:       record.SampleRate,
+		NumberOfChannels: record.NumberOfChannels,
+		PreEventSeconds:  record.PreEventSeconds,
+		Start:            record.Start(),
+		Trigger:          record.StartTime,
+	}
+}
+
+// follows returns whether the buffer continues the Event recording.
+func (e *Event) follows(record Record) bool {
+	last := e.Buffers[len(e.Buffers)-1]
+
+	switch {
+	case record.PreEventSeconds > 0:
+		return false
+	case last.SampleRate != record.SampleRate:
+		return false
+	case last.NumberOfChannels != record.NumberOfChannels:
+		return false
+	case record.BufferNumber != last.BufferNumber%256+1:
+		return false
+	default:
+		return true
+	}
+}
+
+// SamplePeriod returns the nominal time between samples.
+func (e Event) SamplePeriod() time.Duration {
+	if e.SampleRate > 0 {
+		return time.Second / time.Duration(e.SampleRate)
+	}
+	return 0
+}
+
+// EndTime returns the time of the last sample in the Event.
+func (e Event) EndTime() time.Time {
+	if n := len(e.Buffers); n > 0 {
+		return e.Buffers[n-1].EndTime()
+	}
+	return e.Start
+}
+
+// Duration returns the time span covered by the Event samples.
+func (e Event) Duration() time.Duration {
+	if len(e.Buffers) == 0 {
+		return 0
+	}
+	return e.EndTime().Sub(e.Start) + e.SamplePeriod()
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%d %s %s %v %d %d %v", e.Instrument, e.Start.Format(time.RFC3339Nano),
+		e.Trigger.Format(time.RFC3339Nano), e.Duration(), e.NumberOfChannels, len(e.Buffers), e.Complete)
+}
+
+// EventFunc is used as a callback when an Event has been extracted.
+type EventFunc func(Event) error
+
+// Extractor groups the buffers of each instrument into triggered Events, an Event is passed to
+// the callback function once a buffer flagged as the LastTrigger has been added. Buffers that do
+// not follow on from the previous buffer of an instrument, based on the buffer number and recording
+// settings, will start a new Event and the unfinished Event is passed on as incomplete.
+type Extractor struct {
+	fn EventFunc
+
+	events map[int]*Event
+	order  []int
+}
+
+// NewExtractor returns an Extractor that passes each Event to the given callback function.
+func NewExtractor(fn EventFunc) *Extractor {
+	return &Extractor{
+		fn:     fn,
+		events: make(map[int]*Event),
+	}
+}
+
+// Add appends a buffer to the current Event of its instrument.
+func (x *Extractor) Add(record Record) error {
+	if _, ok := x.events[record.Instrument]; !ok {
+		x.order = append(x.order, record.Instrument)
+	}
+
+	if ev := x.events[record.Instrument]; ev != nil && !ev.follows(record) {
+		if err := x.flush(record.Instrument); err != nil {
+			return err
+		}
+	}
+
+	ev := x.events[record.Instrument]
+	if ev == nil {
+		ev = newEvent(record)
+		x.events[record.Instrument] = ev
+	}
+
+	ev.Buffers = append(ev.Buffers, record)
+
+	if record.LastTrigger {
+		ev.Complete = true
+		return x.flush(record.Instrument)
+	}
+
+	return nil
+}
+
+func (x *Extractor) flush(instrument int) error {
+	ev := x.events[instrument]
+	if ev == nil {
+		return nil
+	}
+	x.events[instrument] = nil
+
+	return x.fn(*ev)
+}
+
+// Flush passes any unfinished Events to the callback function, in the order the instruments
+// were first seen. These Events will not be marked as Complete.
+func (x *Extractor) Flush() error {
+	for _, n := range x.order {
+		if err := x.flush(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
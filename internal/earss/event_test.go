@@ -0,0 +1,108 @@
+package earss
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestEvent_Extractor(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extract := func(records []Record) []Event {
+		var events []Event
+		x := NewExtractor(func(ev Event) error {
+			events = append(events, ev)
+			return nil
+		})
+		for _, r := range records {
+			if err := x.Add(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := x.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		return events
+	}
+
+	t.Run("incomplete", func(t *testing.T) {
+		events := extract(records)
+		if len(events) != 1 {
+			t.Fatalf("invalid number of events, expected %d but got %d", 1, len(events))
+		}
+		if events[0].Complete {
+			t.Errorf("event should not be complete without a last trigger")
+		}
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		list := append([]Record(nil), records...)
+		list[len(list)-1].LastTrigger = true
+
+		events := extract(list)
+		if len(events) != 1 {
+			t.Fatalf("invalid number of events, expected %d but got %d", 1, len(events))
+		}
+
+		ev := events[0]
+		if !ev.Complete {
+			t.Errorf("event should be complete")
+		}
+		if n := len(ev.Buffers); n != len(list) {
+			t.Errorf("invalid number of buffers, expected %d but got %d", len(list), n)
+		}
+		if !ev.Trigger.Equal(list[0].StartTime) {
+			t.Errorf("invalid trigger time, expected %v but got %v", list[0].StartTime, ev.Trigger)
+		}
+		if s := ev.Trigger.Sub(ev.Start); s != 10*time.Second {
+			t.Errorf("invalid pre-event time, expected %v but got %v", 10*time.Second, s)
+		}
+		if d := time.Duration(len(list)*list[0].SampleCount()) * ev.SamplePeriod(); ev.Duration() != d {
+			t.Errorf("invalid duration, expected %v but got %v", d, ev.Duration())
+		}
+	})
+
+	t.Run("split", func(t *testing.T) {
+		list := append([]Record(nil), records...)
+		list[0].LastTrigger = true
+
+		events := extract(list)
+		if len(events) != 2 {
+			t.Fatalf("invalid number of events, expected %d but got %d", 2, len(events))
+		}
+		if !events[0].Complete || events[1].Complete {
+			t.Errorf("only the first event should be complete")
+		}
+		if n := len(events[1].Buffers); n != 2 {
+			t.Errorf("invalid number of buffers, expected %d but got %d", 2, n)
+		}
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		// a new pre-event buffer starts a new recording
+		list := append(append([]Record(nil), records...), records...)
+
+		events := extract(list)
+		if len(events) != 2 {
+			t.Fatalf("invalid number of events, expected %d but got %d", 2, len(events))
+		}
+		for _, ev := range events {
+			if ev.Complete {
+				t.Errorf("events should not be complete without a last trigger")
+			}
+			if n := len(ev.Buffers); n != len(records) {
+				t.Errorf("invalid number of buffers, expected %d but got %d", len(records), n)
+			}
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package earss
+
+import (
+	"sort"
+	"time"
+)
+
+// GainSetting is the channel gain configuration of an instrument from the start of a buffer onwards,
+// both as the recorded gain settings and as the GainSystem multipliers.
+type GainSetting struct {
+	Time   time.Time        `json:"time"`
+	Gain   [MaxChannels]int `json:"gain"`
+	System [MaxChannels]int `json:"system"`
+}
+
+// Summary describes the buffers recorded by a single instrument, the distributions of the sample rates,
+// channel counts and buffer types are given as buffer counts.
+type Summary struct {
+	Instrument int       `json:"instrument"`
+	Tapes      []int     `json:"tapes"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Buffers    int       `json:"buffers"`
+	Triggers   int       `json:"triggers"`
+
+	SampleRates map[int]int `json:"sample_rates"`
+	Channels    map[int]int `json:"channels"`
+	BufferTypes map[int]int `json:"buffer_types"`
+
+	// MinTimeCorrection and MaxTimeCorrection are in TimeCorrectionUnit (10 ms) units.
+	MinTimeCorrection int `json:"min_time_correction"`
+	MaxTimeCorrection int `json:"max_time_correction"`
+
+	Gains []GainSetting `json:"gains"`
+}
+
+// newSummary returns a Summary that starts with the given buffer.
+func newSummary(record Record) *Summary {
+	return &Summary{
+		Instrument:        record.Instrument,
+		Start:             record.Start(),
+		End:               record.EndTime(),
+		SampleRates:       make(map[int]int),
+		Channels:          make(map[int]int),
+		BufferTypes:       make(map[int]int),
+		MinTimeCorrection: record.TimeCorrection,
+		MaxTimeCorrection: record.TimeCorrection,
+	}
+}
+
+// add updates the Summary with the details of a buffer.
+func (s *Summary) add(record Record) {
+	s.Buffers++
+	if record.LastTrigger {
+		s.Triggers++
+	}
+
+	if t := record.Start(); t.Before(s.Start) {
+		s.Start = t
+	}
+	if t := record.EndTime(); t.After(s.End) {
+		s.End = t
+	}
+
+	s.SampleRates[record.SampleRate]++
+	s.Channels[record.NumberOfChannels]++
+	s.BufferTypes[record.BufferType]++
+
+	if record.TimeCorrection < s.MinTimeCorrection {
+		s.MinTimeCorrection = record.TimeCorrection
+	}
+	if record.TimeCorrection > s.MaxTimeCorrection {
+		s.MaxTimeCorrection = record.TimeCorrection
+	}
+
+	if i := sort.SearchInts(s.Tapes, record.TapeNumber); i == len(s.Tapes) || s.Tapes[i] != record.TapeNumber {
+		s.Tapes = append(s.Tapes, 0)
+		copy(s.Tapes[i+1:], s.Tapes[i:])
+		s.Tapes[i] = record.TapeNumber
+	}
+
+	// only changes in the gain settings are kept.
+	if n := len(s.Gains); n == 0 || s.Gains[n-1].Gain != record.Gain {
+		setting := GainSetting{Time: record.Start(), Gain: record.Gain}
+		for i, g := range record.Gain {
+			setting.System[i] = systemGain(g)
+		}
+		s.Gains = append(s.Gains, setting)
+	}
+}
+
+// Duration returns the time span covered by the instrument buffers.
+func (s Summary) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// TimeCorrectionRange returns the smallest and largest header time corrections.
+func (s Summary) TimeCorrectionRange() (time.Duration, time.Duration) {
+	return time.Duration(s.MinTimeCorrection) * TimeCorrectionUnit, time.Duration(s.MaxTimeCorrection) * TimeCorrectionUnit
+}
+
+// Inventory accumulates a Summary of the buffers recorded by each instrument, buffers are expected to be
+// added in the order they were read so that gain changes can be followed.
+type Inventory struct {
+	summaries map[int]*Summary
+}
+
+// NewInventory returns an empty Inventory.
+func NewInventory() *Inventory {
+	return &Inventory{
+		summaries: make(map[int]*Summary),
+	}
+}
+
+// Add includes a buffer in the Inventory.
+func (i *Inventory) Add(record Record) {
+	s, ok := i.summaries[record.Instrument]
+	if !ok {
+		s = newSummary(record)
+		i.summaries[record.Instrument] = s
+	}
+	s.add(record)
+}
+
+// Summaries returns the instrument summaries ordered by instrument number.
+func (i *Inventory) Summaries() []Summary {
+	var list []Summary
+	for _, s := range i.summaries {
+		list = append(list, *s)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Instrument < list[j].Instrument
+	})
+
+	return list
+}
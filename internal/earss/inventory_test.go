@@ -0,0 +1,85 @@
+package earss
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestInventory(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := append([]Record(nil), records...)
+	list[1].Gain[0]++
+	list[2].LastTrigger = true
+	list[2].TimeCorrection = -12
+
+	other := records[0]
+	other.Instrument, other.TapeNumber = 7, 2
+
+	inv := NewInventory()
+	for _, r := range append(list, other) {
+		inv.Add(r)
+	}
+
+	summaries := inv.Summaries()
+	if len(summaries) != 2 {
+		t.Fatalf("invalid number of instruments, expected %d but got %d", 2, len(summaries))
+	}
+	if summaries[0].Instrument != 7 || summaries[1].Instrument != records[0].Instrument {
+		t.Errorf("invalid instrument order: %d %d", summaries[0].Instrument, summaries[1].Instrument)
+	}
+
+	s := summaries[1]
+
+	if s.Buffers != len(list) {
+		t.Errorf("invalid buffer count, expected %d but got %d", len(list), s.Buffers)
+	}
+	if s.Triggers != 1 {
+		t.Errorf("invalid trigger count, expected %d but got %d", 1, s.Triggers)
+	}
+	if !s.Start.Equal(records[0].Start()) {
+		t.Errorf("invalid start time, expected %v but got %v", records[0].Start(), s.Start)
+	}
+	if !s.End.Equal(records[2].EndTime()) {
+		t.Errorf("invalid end time, expected %v but got %v", records[2].EndTime(), s.End)
+	}
+	if n := s.SampleRates[100]; n != len(list) {
+		t.Errorf("invalid sample rate count, expected %d but got %d", len(list), n)
+	}
+	if n := s.Channels[3]; n != len(list) {
+		t.Errorf("invalid channel count, expected %d but got %d", len(list), n)
+	}
+	if n := s.BufferTypes[records[0].BufferType]; n != len(list) {
+		t.Errorf("invalid buffer type count, expected %d but got %d", len(list), n)
+	}
+
+	lo, hi := s.TimeCorrectionRange()
+	if lo != -120*time.Millisecond || hi != time.Duration(records[0].TimeCorrection)*TimeCorrectionUnit {
+		t.Errorf("invalid time correction range: %v %v", lo, hi)
+	}
+
+	// the gain change and its reversal should both be listed.
+	if len(s.Gains) != 3 {
+		t.Fatalf("invalid number of gain settings, expected %d but got %d", 3, len(s.Gains))
+	}
+	if !s.Gains[1].Time.Equal(list[1].Start()) || s.Gains[1].Gain != list[1].Gain {
+		t.Errorf("invalid gain change: %v", s.Gains[1])
+	}
+	if g := s.Gains[1].System[0]; g != GainSystem[list[1].Gain[0]] {
+		t.Errorf("invalid system gain, expected %d but got %d", GainSystem[list[1].Gain[0]], g)
+	}
+
+	if len(summaries[0].Tapes) != 1 || summaries[0].Tapes[0] != 2 {
+		t.Errorf("invalid tapes: %v", summaries[0].Tapes)
+	}
+}
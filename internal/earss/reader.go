@@ -0,0 +1,126 @@
+package earss
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrShortBuffer is returned when a stream ends part way through a buffer.
+var ErrShortBuffer = errors.New("short buffer")
+
+// BufferError records which buffer in a stream could not be read or decoded.
+type BufferError struct {
+	Index  int   // buffer index within the stream, starting at zero
+	Offset int64 // byte offset within the stream of the problem
+	Err    error
+}
+
+func (e *BufferError) Error() string {
+	return fmt.Sprintf("buffer %d (offset %d): %v", e.Index, e.Offset, e.Err)
+}
+
+func (e *BufferError) Unwrap() error {
+	return e.Err
+}
+
+// Buffers is implemented by both the Reader and the resync Scanner.
+type Buffers interface {
+	Next() bool
+	Record() Record
+	Err() error
+	Index() int
+}
+
+// NewBuffers returns the reader to use for an input stream, a Scanner is returned if the input needs to be
+// resynchronised, otherwise a Reader is returned and the input is expected to hold aligned buffers.
+func NewBuffers(rd io.Reader, resync bool) Buffers {
+	if resync {
+		return NewScanner(rd)
+	}
+	return NewReader(rd)
+}
+
+// Reader decodes a stream of EARSS buffers one Record at a time.
+type Reader struct {
+	rd     io.Reader
+	buf    []byte
+	index  int
+	offset int64
+	record Record
+	err    error
+	fatal  bool
+}
+
+// NewReader returns a Reader that decodes buffers from the given io.Reader.
+func NewReader(rd io.Reader) *Reader {
+	return &Reader{
+		rd:  rd,
+		buf: make([]byte, BufferLength),
+	}
+}
+
+// Next reads and decodes the next buffer, which is then available via Record.
+// It returns false at the end of the stream or if an error occurred, in which
+// case Err will report the problem. A buffer that was read but could not be
+// decoded is skipped, and Next may be called again to continue with the
+// following buffer.
+func (r *Reader) Next() bool {
+	if r.err != nil {
+		if r.fatal {
+			return false
+		}
+		r.err = nil
+	}
+
+	n, err := io.ReadFull(r.rd, r.buf)
+	switch {
+	case err == io.EOF:
+		return false
+	case err == io.ErrUnexpectedEOF:
+		r.err, r.fatal = &BufferError{
+			Index:  r.index,
+			Offset: r.offset,
+			Err:    fmt.Errorf("%w: found %d of %d bytes", ErrShortBuffer, n, BufferLength),
+		}, true
+		return false
+	case err != nil:
+		r.err, r.fatal = &BufferError{Index: r.index, Offset: r.offset, Err: err}, true
+		return false
+	}
+
+	index, offset := r.index, r.offset
+
+	r.index++
+	r.offset += BufferLength
+
+	var record Record
+	if err := record.Decode(r.buf); err != nil {
+		berr := BufferError{Index: index, Offset: offset, Err: err}
+		if herr, ok := err.(*HeaderError); ok {
+			berr.Offset += int64(herr.Offset)
+		}
+		r.err = &berr
+		return false
+	}
+
+	r.record = record
+
+	return true
+}
+
+// Record returns the most recent Record decoded by Next.
+func (r *Reader) Record() Record {
+	return r.record
+}
+
+// Index returns the number of buffers read so far.
+func (r *Reader) Index() int {
+	return r.index
+}
+
+// Err returns the error that stopped the most recent call to Next, or nil if the
+// stream was read cleanly through to the end.
+func (r *Reader) Err() error {
+	return r.err
+}
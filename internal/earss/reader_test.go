@@ -0,0 +1,114 @@
+package earss
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestReader_Next(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		file, err := os.Open("testdata/lylm0313.dat")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		var count int
+		rd := NewReader(file)
+		for rd.Next() {
+			record := rd.Record()
+			if count >= len(records) {
+				t.Fatalf("too many records, expected %d", len(records))
+			}
+			if record != records[count] {
+				t.Errorf("invalid record %d, does not match decoded record", count+1)
+			}
+			count++
+		}
+		if err := rd.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if count != len(records) {
+			t.Errorf("invalid record count, expected %d but got %d", len(records), count)
+		}
+	})
+
+	t.Run("short buffer", func(t *testing.T) {
+		rd := NewReader(bytes.NewReader(data[:len(data)-10]))
+
+		var count int
+		for rd.Next() {
+			count++
+		}
+		if count != len(records)-1 {
+			t.Errorf("invalid record count, expected %d but got %d", len(records)-1, count)
+		}
+
+		err := rd.Err()
+		if !errors.Is(err, ErrShortBuffer) {
+			t.Fatalf("expected a short buffer error, got %v", err)
+		}
+		var berr *BufferError
+		if !errors.As(err, &berr) {
+			t.Fatalf("expected a buffer error, got %T", err)
+		}
+		if berr.Index != len(records)-1 {
+			t.Errorf("invalid buffer index, expected %d but got %d", len(records)-1, berr.Index)
+		}
+		if n := int64((len(records) - 1) * BufferLength); berr.Offset != n {
+			t.Errorf("invalid buffer offset, expected %d but got %d", n, berr.Offset)
+		}
+	})
+	t.Run("decode", func(t *testing.T) {
+		damaged := append([]byte(nil), data[:len(data)-10]...)
+		damaged[2*BufferLength-HeaderLength+6] = 0
+
+		found, err := Decode(damaged)
+		if !errors.Is(err, ErrShortBuffer) {
+			t.Errorf("expected a short buffer error, got %v", err)
+		}
+		var herr *HeaderError
+		if !errors.As(err, &herr) {
+			t.Errorf("expected a header error, got %v", err)
+		}
+		if len(found) != 1 || found[0] != records[0] {
+			t.Errorf("invalid decoded records, expected only the first record but got %d", len(found))
+		}
+	})
+}
+
+func TestReader_Buffers(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, resync := range []bool{false, true} {
+		rd := NewBuffers(bytes.NewReader(data), resync)
+		if _, ok := rd.(*Scanner); ok != resync {
+			t.Errorf("invalid buffers reader for resync %v: %T", resync, rd)
+		}
+		for rd.Next() {
+		}
+		if err := rd.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if n := rd.Index(); n != 3 {
+			t.Errorf("invalid number of buffers, expected %d but got %d", 3, n)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package earss
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// Skipped describes a range of bytes in a stream that did not hold a recognisable buffer.
+type Skipped struct {
+	Offset int64 // byte offset within the stream of the first skipped byte
+	Length int64 // number of bytes skipped
+}
+
+// Scanner decodes a stream of EARSS buffers that may not be aligned, as found in damaged tape images
+// with dropped bytes, padded blocks or garbage. Rather than assuming buffers follow one another the
+// stream is searched for plausible 16 byte header trailers, any bytes between recognised buffers are
+// skipped and can be listed using Skipped.
+//
+// A buffer that directly follows the previous one is accepted if its header passes the sanity checks,
+// otherwise it also needs to either match the instrument and recording settings of the previous buffer,
+// be followed by another plausible buffer within a buffer length, or be at the end of the stream.
+type Scanner struct {
+	rd  io.Reader
+	buf []byte
+	eof bool
+
+	offset int64 // stream offset of the start of buf
+	start  int64 // stream offset of the current record
+	index  int
+
+	record  Record
+	last    *Record
+	skipped []Skipped
+	err     error
+
+	// the search for following buffers, positions before ahead have been checked and next is the
+	// only plausible position found between the last search and ahead, or negative if none.
+	ahead int
+	next  int
+}
+
+// NewScanner returns a Scanner that searches for buffers in the given io.Reader.
+func NewScanner(rd io.Reader) *Scanner {
+	return &Scanner{
+		rd:   rd,
+		next: -1,
+	}
+}
+
+// fill reads from the stream until the buffer holds at least n bytes or the stream has ended.
+func (s *Scanner) fill(n int) error {
+	chunk := make([]byte, BufferLength)
+	for !s.eof && len(s.buf) < n {
+		c, err := s.rd.Read(chunk)
+		s.buf = append(s.buf, chunk[:c]...)
+		switch {
+		case errors.Is(err, io.EOF):
+			s.eof = true
+		case err != nil:
+			return err
+		}
+	}
+	return nil
+}
+
+// skip drops the leading bytes from the buffer, these are added to the list of skipped ranges.
+func (s *Scanner) skip(n int) {
+	if !(n > 0) {
+		return
+	}
+
+	switch last := len(s.skipped) - 1; {
+	case last >= 0 && s.skipped[last].Offset+s.skipped[last].Length == s.offset:
+		s.skipped[last].Length += int64(n)
+	default:
+		s.skipped = append(s.skipped, Skipped{Offset: s.offset, Length: int64(n)})
+	}
+
+	s.consume(n)
+}
+
+// consume drops the leading bytes from the buffer.
+func (s *Scanner) consume(n int) {
+	s.buf = s.buf[n:]
+	s.offset += int64(n)
+
+	s.ahead, s.next = s.ahead-n, s.next-n
+	if s.ahead < 0 {
+		s.ahead, s.next = 0, -1
+	}
+}
+
+// plausible returns whether the buffer at the given position has a valid header.
+func (s *Scanner) plausible(p int) bool {
+	if p+BufferLength > len(s.buf) {
+		return false
+	}
+	return checkHeader(s.buf[p:p+BufferLength]) == nil
+}
+
+// confirm returns whether a misaligned candidate buffer is likely to be real rather than a chance match.
+func (s *Scanner) confirm(p int, record Record) bool {
+	switch last := s.last; {
+	case last != nil && last.Instrument == record.Instrument && last.SampleRate == record.SampleRate &&
+		last.NumberOfChannels == record.NumberOfChannels:
+		return true
+	case s.eof && p+2*BufferLength > len(s.buf):
+		return true
+	}
+
+	// allow for padding or damage before the next buffer.
+	return s.following(p+BufferLength, p+2*BufferLength)
+}
+
+// following returns whether there is a plausible buffer between the two positions. As candidates are checked
+// in order, the search carries on from where any previous search finished so each position is only checked once.
+func (s *Scanner) following(from, to int) bool {
+	if s.next >= from && s.next < s.ahead {
+		return s.next <= to
+	}
+
+	n := from
+	if n < s.ahead {
+		n = s.ahead
+	}
+	for ; n <= to; n++ {
+		if s.plausible(n) {
+			s.ahead, s.next = n+1, n
+			return true
+		}
+	}
+	s.ahead, s.next = to+1, -1
+
+	return false
+}
+
+// Next searches for and decodes the next buffer, which is then available via Record. It returns false
+// at the end of the stream or if the stream could not be read, in which case Err will report the problem.
+func (s *Scanner) Next() bool {
+	if s.err != nil {
+		return false
+	}
+
+	for {
+		// enough for a candidate buffer to be followed by another after a gap.
+		if err := s.fill(4 * BufferLength); err != nil {
+			s.err = err
+			return false
+		}
+
+		if len(s.buf) < BufferLength {
+			s.skip(len(s.buf))
+			return false
+		}
+
+		// the last position that can be checked with the data at hand.
+		limit := len(s.buf) - BufferLength
+		if !s.eof {
+			limit = len(s.buf) - 3*BufferLength
+		}
+
+		for p := 0; p <= limit; p++ {
+			if !s.plausible(p) {
+				continue
+			}
+
+			var record Record
+			if err := record.Decode(s.buf[p : p+BufferLength]); err != nil {
+				continue
+			}
+			if p > 0 && !s.confirm(p, record) {
+				continue
+			}
+
+			s.skip(p)
+
+			s.start = s.offset
+			s.record, s.last = record, &record
+			s.index++
+
+			s.consume(BufferLength)
+
+			return true
+		}
+
+		// nothing was found, keep any bytes that could be the start of a later buffer.
+		switch {
+		case s.eof:
+			s.skip(len(s.buf))
+			return false
+		default:
+			s.skip(limit + 1)
+		}
+	}
+}
+
+// Record returns the most recent Record decoded by Next.
+func (s *Scanner) Record() Record {
+	return s.record
+}
+
+// Offset returns the byte offset within the stream of the most recent Record.
+func (s *Scanner) Offset() int64 {
+	return s.start
+}
+
+// Index returns the number of buffers found so far.
+func (s *Scanner) Index() int {
+	return s.index
+}
+
+// Skipped returns the byte ranges that have been skipped so far.
+func (s *Scanner) Skipped() []Skipped {
+	return s.skipped
+}
+
+// Err returns the error that stopped the Scanner, or nil if the stream was read through to the end.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// Scan searches a byte slice for EARSS buffers, it returns the decoded Records and any byte ranges that
+// were skipped.
+func Scan(data []byte) ([]Record, []Skipped, error) {
+	var records []Record
+
+	sc := NewScanner(bytes.NewReader(data))
+	for sc.Next() {
+		records = append(records, sc.Record())
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return records, sc.Skipped(), nil
+}
@@ -0,0 +1,158 @@
+package earss
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestScan_Resync(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := func(i int) []byte {
+		return data[i*BufferLength : (i+1)*BufferLength]
+	}
+	garbage := func(n int) []byte {
+		return bytes.Repeat([]byte{0xff}, n)
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	tests := map[string]struct {
+		data    []byte
+		records []int
+		skipped []Skipped
+	}{
+		"aligned": {
+			data:    data,
+			records: []int{0, 1, 2},
+		},
+		"leading garbage": {
+			data:    join(garbage(100), data),
+			records: []int{0, 1, 2},
+			skipped: []Skipped{{0, 100}},
+		},
+		"padding": {
+			data:    join(buffer(0), garbage(37), buffer(1), buffer(2)),
+			records: []int{0, 1, 2},
+			skipped: []Skipped{{BufferLength, 37}},
+		},
+		"dropped bytes": {
+			data:    join(buffer(0), buffer(1)[1000:], buffer(2)),
+			records: []int{0, 2},
+			skipped: []Skipped{{BufferLength, BufferLength - 1000}},
+		},
+		"trailing garbage": {
+			data:    join(data, garbage(BufferLength+10)),
+			records: []int{0, 1, 2},
+			skipped: []Skipped{{3 * BufferLength, BufferLength + 10}},
+		},
+		"damaged": {
+			data:    join(garbage(100), buffer(0), make([]byte, 37), buffer(1)[1000:], buffer(2), garbage(4)),
+			records: []int{0, 2},
+			skipped: []Skipped{{0, 100}, {100 + BufferLength, 37 + BufferLength - 1000}, {100 + 37 + 3*BufferLength - 1000, 4}},
+		},
+		"zero padding": {
+			data:    join(buffer(0), make([]byte, 2*BufferLength+5), buffer(1), buffer(2)),
+			records: []int{0, 1, 2},
+			skipped: []Skipped{{BufferLength, 2*BufferLength + 5}},
+		},
+	}
+
+	for k, v := range tests {
+		t.Run(k, func(t *testing.T) {
+			found, skipped, err := Scan(v.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(found) != len(v.records) {
+				t.Fatalf("invalid record count, expected %d but got %d", len(v.records), len(found))
+			}
+			for i, n := range v.records {
+				if found[i] != records[n] {
+					t.Errorf("invalid record %d, does not match decoded record %d", i+1, n+1)
+				}
+			}
+
+			if len(skipped) != len(v.skipped) {
+				t.Fatalf("invalid skipped ranges, expected %v but got %v", v.skipped, skipped)
+			}
+			for i := range skipped {
+				if skipped[i] != v.skipped[i] {
+					t.Errorf("invalid skipped range, expected %v but got %v", v.skipped[i], skipped[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScan_Offset(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	padded := bytes.Join([][]byte{data[:BufferLength], make([]byte, 37), data[BufferLength:]}, nil)
+
+	offsets := []int64{0, BufferLength + 37, 2*BufferLength + 37}
+
+	sc := NewScanner(bytes.NewReader(padded))
+	for i := 0; sc.Next(); i++ {
+		if i >= len(offsets) {
+			t.Fatalf("too many records, expected %d", len(offsets))
+		}
+		if o := sc.Offset(); o != offsets[i] {
+			t.Errorf("invalid offset for record %d, expected %d but got %d", i+1, offsets[i], o)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n := sc.Index(); n != len(offsets) {
+		t.Errorf("invalid record count, expected %d but got %d", len(offsets), n)
+	}
+}
+
+func TestScan_Garbage(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// almost a buffer length of bytes that look like headers, so every buffer ending within the run is a
+	// candidate without a following buffer to confirm it.
+	run := bytes.Repeat([]byte{0x01}, BufferLength-HeaderLength)
+	garbage := bytes.Repeat([]byte{0xff}, BufferLength)
+	input := bytes.Join([][]byte{garbage, run, garbage, garbage, garbage, data}, nil)
+
+	start := time.Now()
+
+	found, skipped, err := Scan(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("scanning took too long: %v", d)
+	}
+
+	if len(found) != 3 {
+		t.Fatalf("invalid record count, expected %d but got %d", 3, len(found))
+	}
+	if n := int64(len(input) - len(data)); len(skipped) != 1 || skipped[0] != (Skipped{0, n}) {
+		t.Errorf("invalid skipped ranges, expected %v but got %v", []Skipped{{0, n}}, skipped)
+	}
+}
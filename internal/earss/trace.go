@@ -0,0 +1,456 @@
+package earss
+
+import (
+	"math"
+	"time"
+)
+
+// TimeResolution is the precision of the EARSS header start times.
+const TimeResolution = 10 * time.Millisecond
+
+// Trace is a contiguous run of samples from a single instrument channel.
+type Trace struct {
+	Instrument int
+	Channel    int
+	SampleRate int
+	Start      time.Time
+	Buffers    int
+	Samples    []int32
+
+	// Channels is the number of channels in each buffer, and Times holds the start time of
+	// each buffer, these are used to measure the actual sample rate.
+	Channels int
+	Times    []time.Time
+
+	Gain           int
+	TimeCorrection int // in TimeCorrectionUnit (10 ms) units
+}
+
+// SamplePeriod returns the nominal time between samples.
+func (t Trace) SamplePeriod() time.Duration {
+	if t.SampleRate > 0 {
+		return time.Second / time.Duration(t.SampleRate)
+	}
+	return 0
+}
+
+// Correction returns the time correction that applies to the Trace samples.
+func (t Trace) Correction() time.Duration {
+	return time.Duration(t.TimeCorrection) * TimeCorrectionUnit
+}
+
+// TimingQuality returns an estimate of the SEED timing quality, as a percentage, for the Trace samples.
+// As the header times are only recorded to the TimeResolution the estimate starts at 90, this is then
+// reduced by one for each TimeCorrectionUnit of correction, as a large correction indicates the recorder
+// clock had drifted, down to a minimum of 10.
+func (t Trace) TimingQuality() int {
+	drift := t.TimeCorrection
+	if drift < 0 {
+		drift = -drift
+	}
+	if q := 90 - drift; q > 10 {
+		return q
+	}
+	return 10
+}
+
+// MeasuredRate estimates the actual sample rate from the buffer start times, using a least squares fit
+// of the start times against the sample offset of each buffer. As the header times are only recorded to the
+// TimeResolution the buffer start times need to cover at least the given span, otherwise no estimate is made
+// and the nominal rate should be used.
+func (t Trace) MeasuredRate(span time.Duration) (float64, bool) {
+	if len(t.Times) < 2 || !(t.Channels > 0) {
+		return 0, false
+	}
+	if t.Times[len(t.Times)-1].Sub(t.Times[0]) < span {
+		return 0, false
+	}
+
+	// the number of samples per channel in each buffer.
+	count := float64(DataValues / t.Channels)
+
+	var sx, sy, sxx, sxy float64
+	for i, at := range t.Times {
+		x, y := float64(i)*count, at.Sub(t.Times[0]).Seconds()
+		sx, sy, sxx, sxy = sx+x, sy+y, sxx+x*x, sxy+x*y
+	}
+
+	n := float64(len(t.Times))
+	if d := n*sxx - sx*sx; d != 0 {
+		if period := (n*sxy - sx*sy) / d; period > 0 {
+			return 1.0 / period, true
+		}
+	}
+
+	return 0, false
+}
+
+// Calibrated returns the Trace samples normalised by the channel system gain.
+func (t Trace) Calibrated() []float64 {
+	gain := float64(systemGain(t.Gain))
+
+	samples := make([]float64, 0, len(t.Samples))
+	for _, v := range t.Samples {
+		samples = append(samples, float64(v)/gain)
+	}
+	return samples
+}
+
+// EndTime returns the time of the last sample in the Trace.
+func (t Trace) EndTime() time.Time {
+	if len(t.Samples) == 0 {
+		return t.Start
+	}
+	return t.Start.Add(time.Duration(len(t.Samples)-1) * t.SamplePeriod())
+}
+
+// sampleOffset returns the time of the nth sample from the start at the given sample rate, it is calculated
+// in the same way as the miniseed record sample offsets so that split times match the packed record times.
+func sampleOffset(rate float64, n int) time.Duration {
+	return time.Duration(math.Round(float64(n) / rate * float64(time.Second)))
+}
+
+// SplitDays breaks the Trace at any UTC day boundaries, each returned Trace will only hold samples
+// from a single day. The sample times are found using the given rate, or the nominal rate if it is zero.
+func (t Trace) SplitDays(rate float64) []Trace {
+	if !(rate > 0) {
+		rate = float64(t.SampleRate)
+	}
+	if !(rate > 0) || len(t.Samples) == 0 {
+		return []Trace{t}
+	}
+
+	end := t.Start.Add(sampleOffset(rate, len(t.Samples)-1))
+
+	var times []time.Time
+	for y, m, d := t.Start.Date(); ; d++ {
+		next := time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+		if next.After(end) {
+			break
+		}
+		times = append(times, next)
+	}
+	return t.SplitAt(rate, times...)
+}
+
+// SplitAt breaks the Trace at the given times, which are expected to be in order. Each returned Trace will
+// only hold samples from between consecutive times, the first sample on or after a time starts a new Trace.
+// The sample times are found using the given rate, or the nominal rate if it is zero.
+func (t Trace) SplitAt(rate float64, times ...time.Time) []Trace {
+	if !(rate > 0) {
+		rate = float64(t.SampleRate)
+	}
+	if !(rate > 0) {
+		return []Trace{t}
+	}
+
+	start, samples := t.Start, t.Samples
+
+	var traces []Trace
+
+	var offset int
+	for _, at := range times {
+		if !at.After(t.Start) {
+			continue
+		}
+
+		// the index of the first sample on or after the boundary
+		n := int(math.Ceil(at.Sub(start).Seconds() * rate))
+		for n > offset && !start.Add(sampleOffset(rate, n-1)).Before(at) {
+			n--
+		}
+		for start.Add(sampleOffset(rate, n)).Before(at) {
+			n++
+		}
+		if n >= len(samples) {
+			break
+		}
+
+		head := t
+		head.Samples = samples[offset:n:n]
+		traces = append(traces, head)
+
+		t.Start = start.Add(sampleOffset(rate, n))
+		t.Samples = samples[n:]
+
+		offset = n
+	}
+
+	return append(traces, t)
+}
+
+// Discontinuity describes a break between consecutive buffers from an instrument.
+type Discontinuity struct {
+	Instrument int
+	Previous   int       // the previous buffer number
+	Buffer     int       // the buffer number that could not be joined
+	Expected   time.Time // the expected start of the buffer
+	Start      time.Time // the actual start of the buffer
+}
+
+// Gap returns the time difference between the actual and expected buffer start,
+// a negative value indicates an overlap.
+func (d Discontinuity) Gap() time.Duration {
+	return d.Start.Sub(d.Expected)
+}
+
+// IsGap returns whether data is missing between the buffers.
+func (d Discontinuity) IsGap(tolerance time.Duration) bool {
+	return d.Gap() > tolerance
+}
+
+// IsOverlap returns whether the buffers share a time span.
+func (d Discontinuity) IsOverlap(tolerance time.Duration) bool {
+	return d.Gap() < -tolerance
+}
+
+// GainChange records a change in the system gain of an instrument channel.
+type GainChange struct {
+	Instrument int
+	Channel    int
+	Buffer     int       // the buffer number with the new gain
+	At         time.Time // the time of the first sample with the new gain
+	From       int
+	To         int
+}
+
+// TraceFunc is used as a callback when a Trace has been assembled.
+type TraceFunc func(Trace) error
+
+type run struct {
+	last   Record
+	first  int
+	traces []Trace
+}
+
+// Assembler joins consecutive buffers into continuous Traces for each instrument channel,
+// a Trace is passed to the callback function whenever a discontinuity is found or when
+// the Assembler is flushed. To limit memory use a long Trace can also be passed on once
+// it reaches a sample limit or crosses a UTC day boundary, the following buffers are then
+// assembled into a new Trace without being reported as a discontinuity.
+type Assembler struct {
+	tolerance time.Duration
+	fn        TraceFunc
+
+	maxSamples int
+	daily      bool
+	splitGains bool
+
+	runs   map[int]*run
+	order  []int
+	added  int
+	breaks []Discontinuity
+	gains  []GainChange
+}
+
+// NewAssembler returns an Assembler that joins buffers whose start times are within the
+// given tolerance of the expected time, a zero tolerance will use the TimeResolution.
+func NewAssembler(tolerance time.Duration, fn TraceFunc) *Assembler {
+	if !(tolerance > 0) {
+		tolerance = TimeResolution
+	}
+	return &Assembler{
+		tolerance:  tolerance,
+		fn:         fn,
+		splitGains: true,
+		runs:       make(map[int]*run),
+	}
+}
+
+// SetMaxSamples limits the number of samples held in each Trace, a zero value removes the limit.
+// A Trace may hold fewer samples, as buffers are never divided between Traces.
+func (a *Assembler) SetMaxSamples(n int) {
+	a.maxSamples = n
+}
+
+// SetSplitGains sets whether a new Trace is started when a channel gain changes, this is only needed
+// when the samples are to be calibrated. Gain changes are still reported, but the Trace Gain will
+// then be the gain of its first buffer.
+func (a *Assembler) SetSplitGains(split bool) {
+	a.splitGains = split
+}
+
+// SetDaily sets whether a Trace is passed on when a buffer starts on a later UTC day than the previous buffer.
+func (a *Assembler) SetDaily(daily bool) {
+	a.daily = daily
+}
+
+// full returns whether the existing Traces should be passed on before adding the buffer.
+func (a *Assembler) full(r *run, record Record) bool {
+	switch {
+	case r.traces == nil:
+		return false
+	case a.maxSamples > 0 && len(r.traces[0].Samples)+record.SampleCount() > a.maxSamples:
+		return true
+	case a.daily && !sameDay(r.last.Start(), record.Start()):
+		return true
+	default:
+		return false
+	}
+}
+
+// sameDay returns whether two times fall on the same UTC day.
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.UTC().Date()
+	by, bm, bd := b.UTC().Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// Tolerance returns the time tolerance used to decide whether buffers are contiguous.
+func (a *Assembler) Tolerance() time.Duration {
+	return a.tolerance
+}
+
+// Discontinuities returns the breaks found between buffers so far.
+func (a *Assembler) Discontinuities() []Discontinuity {
+	return a.breaks
+}
+
+// GainChanges returns the changes in channel gain found between buffers so far, unless disabled
+// each change will also have started a new Trace.
+func (a *Assembler) GainChanges() []GainChange {
+	return a.gains
+}
+
+// Contiguous returns whether the next buffer directly follows the previous one.
+func (a *Assembler) Contiguous(prev, next Record) bool {
+	switch {
+	case prev.Instrument != next.Instrument:
+		return false
+	case prev.SampleRate != next.SampleRate:
+		return false
+	case prev.NumberOfChannels != next.NumberOfChannels:
+		return false
+	case prev.TimeCorrection != next.TimeCorrection:
+		return false
+	case next.BufferNumber != prev.BufferNumber%256+1:
+		return false
+	}
+
+	gap := next.Start().Sub(prev.EndTime().Add(prev.SamplePeriod()))
+	if gap < 0 {
+		gap = -gap
+	}
+
+	return !(gap > a.tolerance)
+}
+
+// Add appends a Record to the Trace of each of its channels, any existing Traces
+// are passed to the callback function if the Record is not contiguous.
+func (a *Assembler) Add(record Record) error {
+	r, ok := a.runs[record.Instrument]
+	if !ok {
+		r = &run{}
+		a.runs[record.Instrument] = r
+		a.order = append(a.order, record.Instrument)
+	}
+
+	switch {
+	case r.traces == nil:
+	case !a.Contiguous(r.last, record):
+		a.breaks = append(a.breaks, Discontinuity{
+			Instrument: record.Instrument,
+			Previous:   r.last.BufferNumber,
+			Buffer:     record.BufferNumber,
+			Expected:   r.last.EndTime().Add(r.last.SamplePeriod()),
+			Start:      record.Start(),
+		})
+		if err := a.flush(r); err != nil {
+			return err
+		}
+	case r.last.Gain != record.Gain:
+		for i := 0; i < record.NumberOfChannels; i++ {
+			if r.last.Gain[i] == record.Gain[i] {
+				continue
+			}
+			a.gains = append(a.gains, GainChange{
+				Instrument: record.Instrument,
+				Channel:    i,
+				Buffer:     record.BufferNumber,
+				At:         record.Start(),
+				From:       r.last.Gain[i],
+				To:         record.Gain[i],
+			})
+		}
+		if !a.splitGains {
+			break
+		}
+		if err := a.flush(r); err != nil {
+			return err
+		}
+	}
+
+	if a.full(r, record) {
+		if err := a.flush(r); err != nil {
+			return err
+		}
+	}
+
+	if r.traces == nil {
+		r.first = a.added
+		for i := 0; i < record.NumberOfChannels; i++ {
+			r.traces = append(r.traces, Trace{
+				Instrument: record.Instrument,
+				Channel:    i,
+				SampleRate: record.SampleRate,
+				Start:      record.Start(),
+				Channels:   record.NumberOfChannels,
+
+				Gain:           record.Gain[i],
+				TimeCorrection: record.TimeCorrection,
+			})
+		}
+	}
+
+	for i := range r.traces {
+		r.traces[i].Samples = append(r.traces[i].Samples, record.Channel(i)...)
+		r.traces[i].Times = append(r.traces[i].Times, record.Start())
+		r.traces[i].Buffers++
+	}
+
+	r.last = record
+	a.added++
+
+	return nil
+}
+
+// Checkpoint returns the number of buffers, counted in the order they were added, that have been passed on
+// to the callback function. The flag indicates whether these are exactly the first buffers added, as once
+// instruments are interleaved a later buffer may have been passed on while an earlier one is still held.
+func (a *Assembler) Checkpoint() (int, bool) {
+	oldest, held := a.added, 0
+	for _, r := range a.runs {
+		if r.traces == nil {
+			continue
+		}
+		if r.first < oldest {
+			oldest = r.first
+		}
+		held += r.traces[0].Buffers
+	}
+	return oldest, a.added-held == oldest
+}
+
+func (a *Assembler) flush(r *run) error {
+	traces := r.traces
+	r.traces = nil
+
+	for _, t := range traces {
+		if err := a.fn(t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Flush passes any outstanding Traces to the callback function, in the order the
+// instruments were first seen.
+func (a *Assembler) Flush() error {
+	for _, n := range a.order {
+		if err := a.flush(a.runs[n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
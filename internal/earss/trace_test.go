@@ -0,0 +1,482 @@
+package earss
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTrace_Assembler(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("contiguous", func(t *testing.T) {
+		var traces []Trace
+		asm := NewAssembler(0, func(trace Trace) error {
+			traces = append(traces, trace)
+			return nil
+		})
+		for _, r := range records {
+			if err := asm.Add(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := asm.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		if n := len(asm.Discontinuities()); n != 0 {
+			t.Errorf("invalid number of discontinuities, expected %d but got %d", 0, n)
+		}
+		if len(traces) != 3 {
+			t.Fatalf("invalid number of traces, expected %d but got %d", 3, len(traces))
+		}
+		for i, trace := range traces {
+			if trace.Channel != i {
+				t.Errorf("invalid trace channel, expected %d but got %d", i, trace.Channel)
+			}
+			if n := len(records) * records[0].SampleCount(); len(trace.Samples) != n {
+				t.Errorf("invalid trace length, expected %d but got %d", n, len(trace.Samples))
+			}
+			if s := trace.Start; !s.Equal(records[0].Start()) {
+				t.Errorf("invalid trace start, expected %v but got %v", records[0].Start(), s)
+			}
+			if s := trace.EndTime(); !s.Equal(records[len(records)-1].EndTime()) {
+				t.Errorf("invalid trace end, expected %v but got %v", records[len(records)-1].EndTime(), s)
+			}
+		}
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		shifted := append([]Record(nil), records...)
+		shifted[2].StartTime = shifted[2].StartTime.Add(time.Second)
+
+		var traces []Trace
+		asm := NewAssembler(0, func(trace Trace) error {
+			traces = append(traces, trace)
+			return nil
+		})
+		for _, r := range shifted {
+			if err := asm.Add(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := asm.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(traces) != 6 {
+			t.Fatalf("invalid number of traces, expected %d but got %d", 6, len(traces))
+		}
+
+		breaks := asm.Discontinuities()
+		if len(breaks) != 1 {
+			t.Fatalf("invalid number of discontinuities, expected %d but got %d", 1, len(breaks))
+		}
+		if g := breaks[0].Gap(); g != time.Second {
+			t.Errorf("invalid gap, expected %v but got %v", time.Second, g)
+		}
+		if !breaks[0].IsGap(asm.Tolerance()) || breaks[0].IsOverlap(asm.Tolerance()) {
+			t.Errorf("expected a gap, not an overlap")
+		}
+	})
+
+	t.Run("overlap", func(t *testing.T) {
+		shifted := append([]Record(nil), records...)
+		shifted[1].StartTime = shifted[1].StartTime.Add(-time.Second)
+
+		asm := NewAssembler(0, func(trace Trace) error {
+			return nil
+		})
+		for _, r := range shifted {
+			if err := asm.Add(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		breaks := asm.Discontinuities()
+		if len(breaks) != 2 {
+			t.Fatalf("invalid number of discontinuities, expected %d but got %d", 2, len(breaks))
+		}
+		if !breaks[0].IsOverlap(asm.Tolerance()) {
+			t.Errorf("expected an overlap, got %v", breaks[0].Gap())
+		}
+	})
+}
+
+func TestTrace_Limits(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assemble := func(records []Record, setup func(*Assembler)) ([]Trace, *Assembler) {
+		var traces []Trace
+		asm := NewAssembler(0, func(trace Trace) error {
+			traces = append(traces, trace)
+			return nil
+		})
+		setup(asm)
+		for _, r := range records {
+			if err := asm.Add(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := asm.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		return traces, asm
+	}
+
+	t.Run("samples", func(t *testing.T) {
+		traces, asm := assemble(records, func(asm *Assembler) {
+			asm.SetMaxSamples(2*records[0].SampleCount() + 1)
+		})
+
+		if n := len(asm.Discontinuities()); n != 0 {
+			t.Errorf("invalid number of discontinuities, expected %d but got %d", 0, n)
+		}
+		if len(traces) != 6 {
+			t.Fatalf("invalid number of traces, expected %d but got %d", 6, len(traces))
+		}
+		if n := traces[0].Buffers; n != 2 {
+			t.Errorf("invalid number of buffers, expected %d but got %d", 2, n)
+		}
+		if s := traces[3].Start; !s.Equal(records[2].Start()) {
+			t.Errorf("invalid trace start, expected %v but got %v", records[2].Start(), s)
+		}
+		if s := append(traces[0].Samples, traces[3].Samples...); len(s) != len(records)*records[0].SampleCount() {
+			t.Errorf("invalid sample count, expected %d but got %d", len(records)*records[0].SampleCount(), len(s))
+		}
+	})
+
+	t.Run("daily", func(t *testing.T) {
+		// move the recording so that the last buffer starts just after midnight.
+		start := records[2].Start()
+		y, m, d := start.Date()
+		shift := time.Date(y, m, d+1, 0, 0, 1, 0, time.UTC).Sub(start)
+
+		shifted := append([]Record(nil), records...)
+		for i := range shifted {
+			shifted[i].StartTime = shifted[i].StartTime.Add(shift)
+		}
+
+		traces, asm := assemble(shifted, func(asm *Assembler) {
+			asm.SetDaily(true)
+		})
+
+		if n := len(asm.Discontinuities()); n != 0 {
+			t.Errorf("invalid number of discontinuities, expected %d but got %d", 0, n)
+		}
+		if len(traces) != 6 {
+			t.Fatalf("invalid number of traces, expected %d but got %d", 6, len(traces))
+		}
+		if s := traces[3].Start; !s.Equal(shifted[2].Start()) {
+			t.Errorf("invalid trace start, expected %v but got %v", shifted[2].Start(), s)
+		}
+	})
+}
+
+func TestTrace_Checkpoint(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a second instrument whose buffers are not contiguous with each other.
+	other := records[2]
+	other.Instrument = 7
+	later := other
+	later.StartTime = later.StartTime.Add(time.Hour)
+
+	// the first buffers of instrument 106 are not contiguous with the last.
+	last := records[2]
+	last.StartTime = last.StartTime.Add(time.Hour)
+
+	steps := []struct {
+		record Record
+		count  int
+		ok     bool
+	}{
+		{record: records[0], count: 0, ok: true},
+		{record: records[1], count: 0, ok: true},
+		{record: other, count: 0, ok: true},
+		// the buffer of instrument 7 is passed on while the earlier buffers are still held.
+		{record: later, count: 0, ok: false},
+		// the first three buffers have now been passed on, but not the fourth.
+		{record: last, count: 3, ok: true},
+	}
+
+	asm := NewAssembler(0, func(trace Trace) error {
+		return nil
+	})
+	for i, s := range steps {
+		if err := asm.Add(s.record); err != nil {
+			t.Fatal(err)
+		}
+		if n, ok := asm.Checkpoint(); n != s.count || ok != s.ok {
+			t.Errorf("invalid checkpoint after buffer %d, expected %d %v but got %d %v", i, s.count, s.ok, n, ok)
+		}
+	}
+
+	if err := asm.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := asm.Checkpoint(); n != len(steps) || !ok {
+		t.Errorf("invalid checkpoint after flushing, expected %d %v but got %d %v", len(steps), true, n, ok)
+	}
+}
+
+func TestTrace_SplitDays(t *testing.T) {
+
+	trace := Trace{
+		SampleRate: 100,
+		Start:      time.Date(1994, 3, 12, 23, 59, 59, 995000000, time.UTC),
+		Samples:    make([]int32, 8640002),
+	}
+
+	traces := trace.SplitDays(0)
+	if len(traces) != 3 {
+		t.Fatalf("invalid number of traces, expected %d but got %d", 3, len(traces))
+	}
+
+	var count int
+	for i, tr := range traces {
+		if d := tr.Start.YearDay(); d != tr.EndTime().YearDay() {
+			t.Errorf("trace %d spans more than one day: %v to %v", i, tr.Start, tr.EndTime())
+		}
+		count += len(tr.Samples)
+	}
+	if count != len(trace.Samples) {
+		t.Errorf("invalid sample count, expected %d but got %d", len(trace.Samples), count)
+	}
+
+	if s := traces[1].Start; !s.Equal(time.Date(1994, 3, 13, 0, 0, 0, 5000000, time.UTC)) {
+		t.Errorf("invalid second trace start: %v", s)
+	}
+	if n := len(traces[1].Samples); n != 8640000 {
+		t.Errorf("invalid second trace length, expected %d but got %d", 8640000, n)
+	}
+}
+
+func TestTrace_SplitDaysRate(t *testing.T) {
+
+	trace := Trace{
+		SampleRate: 100,
+		Start:      time.Date(1994, 3, 12, 23, 0, 0, 0, time.UTC),
+		Samples:    make([]int32, 720000),
+	}
+
+	// a measured rate moves the day boundary away from where the nominal period would put it.
+	for _, rate := range []float64{99.99, 100, 100.01} {
+		t.Run(fmt.Sprintf("rate %g", rate), func(t *testing.T) {
+			traces := trace.SplitDays(rate)
+			if len(traces) != 2 {
+				t.Fatalf("invalid number of traces, expected %d but got %d", 2, len(traces))
+			}
+
+			midnight := time.Date(1994, 3, 13, 0, 0, 0, 0, time.UTC)
+
+			n := len(traces[0].Samples)
+			if last := trace.Start.Add(sampleOffset(rate, n-1)); !last.Before(midnight) {
+				t.Errorf("invalid last sample of the first day: %v", last)
+			}
+			if first := trace.Start.Add(sampleOffset(rate, n)); !first.Equal(traces[1].Start) || first.Before(midnight) {
+				t.Errorf("invalid start of the second day, expected %v but got %v", first, traces[1].Start)
+			}
+			if m := n + len(traces[1].Samples); m != len(trace.Samples) {
+				t.Errorf("invalid sample count, expected %d but got %d", len(trace.Samples), m)
+			}
+		})
+	}
+}
+
+func TestTrace_GainChange(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records[2].Gain[1] = 5
+
+	var traces []Trace
+	asm := NewAssembler(0, func(trace Trace) error {
+		traces = append(traces, trace)
+		return nil
+	})
+	for _, r := range records {
+		if err := asm.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := asm.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(asm.Discontinuities()); n != 0 {
+		t.Errorf("invalid number of discontinuities, expected %d but got %d", 0, n)
+	}
+
+	changes := asm.GainChanges()
+	if len(changes) != 1 {
+		t.Fatalf("invalid number of gain changes, expected %d but got %d", 1, len(changes))
+	}
+	if c := changes[0]; c.Channel != 1 || c.From != 3 || c.To != 5 || !c.At.Equal(records[2].Start()) {
+		t.Errorf("invalid gain change: %+v", c)
+	}
+
+	if len(traces) != 6 {
+		t.Fatalf("invalid number of traces, expected %d but got %d", 6, len(traces))
+	}
+	if g := traces[4].Gain; g != 5 {
+		t.Errorf("invalid trace gain, expected %d but got %d", 5, g)
+	}
+	if v, s := traces[4].Calibrated()[0], float64(traces[4].Samples[0])/32.0; v != s {
+		t.Errorf("invalid calibrated sample, expected %g but got %g", s, v)
+	}
+	t.Run("joined", func(t *testing.T) {
+		var traces []Trace
+		asm := NewAssembler(0, func(trace Trace) error {
+			traces = append(traces, trace)
+			return nil
+		})
+		asm.SetSplitGains(false)
+		for _, r := range records {
+			if err := asm.Add(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := asm.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		if n := len(asm.GainChanges()); n != 1 {
+			t.Errorf("invalid number of gain changes, expected %d but got %d", 1, n)
+		}
+		if len(traces) != 3 {
+			t.Fatalf("invalid number of traces, expected %d but got %d", 3, len(traces))
+		}
+		if g := traces[1].Gain; g != 3 {
+			t.Errorf("invalid trace gain, expected %d but got %d", 3, g)
+		}
+	})
+}
+
+func TestTrace_TimingQuality(t *testing.T) {
+	tests := map[int]int{
+		0:    90,
+		54:   36,
+		-54:  36,
+		80:   10,
+		1000: 10,
+	}
+
+	for correction, quality := range tests {
+		trace := Trace{TimeCorrection: correction}
+		if q := trace.TimingQuality(); q != quality {
+			t.Errorf("invalid timing quality for a correction of %d, expected %d but got %d", correction, quality, q)
+		}
+	}
+}
+
+func TestTrace_MeasuredRate(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	measure := func(records []Record) (float64, bool) {
+		var traces []Trace
+		asm := NewAssembler(0, func(trace Trace) error {
+			traces = append(traces, trace)
+			return nil
+		})
+		for _, r := range records {
+			if err := asm.Add(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := asm.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if len(traces) != records[0].NumberOfChannels {
+			t.Fatalf("invalid number of traces, expected %d but got %d", records[0].NumberOfChannels, len(traces))
+		}
+		return traces[0].MeasuredRate(30 * time.Second)
+	}
+
+	t.Run("nominal", func(t *testing.T) {
+		rate, ok := measure(records)
+		if !ok {
+			t.Fatal("unable to measure the sample rate")
+		}
+		if math.Abs(rate-100.0) > 1.0e-6 {
+			t.Errorf("invalid measured rate, expected %g but got %g", 100.0, rate)
+		}
+	})
+
+	t.Run("drift", func(t *testing.T) {
+		// each buffer starts 10 ms later than expected
+		drift := append([]Record(nil), records...)
+		for i := range drift {
+			drift[i].StartTime = drift[i].StartTime.Add(time.Duration(i) * TimeResolution)
+		}
+
+		count := float64(drift[0].SampleCount())
+		expected := count / (count/100.0 + TimeResolution.Seconds())
+
+		rate, ok := measure(drift)
+		if !ok {
+			t.Fatal("unable to measure the sample rate")
+		}
+		if math.Abs(rate-expected) > 1.0e-6 {
+			t.Errorf("invalid measured rate, expected %g but got %g", expected, rate)
+		}
+	})
+
+	t.Run("single", func(t *testing.T) {
+		if _, ok := measure(records[:1]); ok {
+			t.Errorf("a single buffer should not give a measured rate")
+		}
+	})
+
+	t.Run("short", func(t *testing.T) {
+		// two buffers span less than the 30 seconds required.
+		if _, ok := measure(records[:2]); ok {
+			t.Errorf("a short span of buffers should not give a measured rate")
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package earss
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrBadLength     = errors.New("invalid buffer length")
+	ErrBadTime       = errors.New("invalid start time")
+	ErrBadChannels   = errors.New("invalid channel settings")
+	ErrBadSampleRate = errors.New("invalid sample rate")
+	ErrBadInstrument = errors.New("invalid instrument id")
+	ErrBadGain       = errors.New("invalid gain setting")
+	ErrBadField      = errors.New("invalid header field")
+)
+
+// HeaderError describes an EARSS header field that failed validation.
+type HeaderError struct {
+	Offset int    // byte offset of the field within the buffer
+	Field  string // name of the header field
+	Value  int    // the offending value
+	Err    error
+}
+
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("%v: %s %d", e.Err, e.Field, e.Value)
+}
+
+func (e *HeaderError) Unwrap() error {
+	return e.Err
+}
+
+// header field offsets within a buffer.
+const (
+	offsetFlags      = DataLength + 0
+	offsetBuffer     = DataLength + 1
+	offsetChannels   = DataLength + 2
+	offsetGains      = DataLength + 3
+	offsetPreEvent   = DataLength + 4
+	offsetYear       = DataLength + 5
+	offsetMonth      = DataLength + 6
+	offsetDay        = DataLength + 7
+	offsetCorrection = DataLength + 8
+	offsetHour       = DataLength + 10
+	offsetMinute     = DataLength + 11
+	offsetSecond     = DataLength + 12
+	offsetHundredths = DataLength + 13
+	offsetInstrument = DataLength + 14
+	offsetTape       = DataLength + 15
+)
+
+// checkHeader runs sanity checks over the raw header of a buffer before it is decoded, it
+// expects the full buffer so that any offsets are relative to the start of the buffer.
+func checkHeader(data []byte) error {
+	header := data[BufferLength-HeaderLength:]
+
+	// the channel count is stored as two bits, but only three channels are supported.
+	if n := int(header[2]&3) + 1; n > MaxChannels {
+		return &HeaderError{Offset: offsetChannels, Field: "channels", Value: n, Err: ErrBadChannels}
+	}
+	// the bits between the channel count and the first gain, and above the third gain, are not
+	// described by the format so they are ignored rather than treated as damage.
+
+	if y := int(header[5]); y > 99 {
+		return &HeaderError{Offset: offsetYear, Field: "year", Value: y, Err: ErrBadTime}
+	}
+	year := decodeYear(header[5])
+
+	if m := int(header[6]); m < 1 || m > 12 {
+		return &HeaderError{Offset: offsetMonth, Field: "month", Value: m, Err: ErrBadTime}
+	}
+	if d := int(header[7]); d < 1 || d > daysIn(year, time.Month(header[6])) {
+		return &HeaderError{Offset: offsetDay, Field: "day", Value: d, Err: ErrBadTime}
+	}
+	if h := int(header[10]); h > 23 {
+		return &HeaderError{Offset: offsetHour, Field: "hour", Value: h, Err: ErrBadTime}
+	}
+	if m := int(header[11]); m > 59 {
+		return &HeaderError{Offset: offsetMinute, Field: "minute", Value: m, Err: ErrBadTime}
+	}
+	if s := int(header[12]); s > 59 {
+		return &HeaderError{Offset: offsetSecond, Field: "second", Value: s, Err: ErrBadTime}
+	}
+	if c := int(header[13]); c > 99 {
+		return &HeaderError{Offset: offsetHundredths, Field: "hundredths", Value: c, Err: ErrBadTime}
+	}
+
+	if n := int(header[14]); n == 0 {
+		return &HeaderError{Offset: offsetInstrument, Field: "instrument", Value: n, Err: ErrBadInstrument}
+	}
+
+	return nil
+}
+
+// Validate checks that the Record settings could be represented in an EARSS header.
+func (r Record) Validate() error {
+	switch r.SampleRate {
+	case 25, 50, 100, 200:
+	default:
+		return &HeaderError{Offset: offsetFlags, Field: "sample rate", Value: r.SampleRate, Err: ErrBadSampleRate}
+	}
+	if n := r.NumberOfChannels; n < 1 || n > MaxChannels {
+		return &HeaderError{Offset: offsetChannels, Field: "channels", Value: n, Err: ErrBadChannels}
+	}
+	for _, g := range r.Gain {
+		if g < 0 || g >= len(GainSystem) {
+			return &HeaderError{Offset: offsetGains, Field: "gain", Value: g, Err: ErrBadGain}
+		}
+	}
+	if n := r.Instrument; n < 1 || n > 255 {
+		return &HeaderError{Offset: offsetInstrument, Field: "instrument", Value: n, Err: ErrBadInstrument}
+	}
+	if y := r.StartTime.Year(); y < 1950 || y > 2049 {
+		return &HeaderError{Offset: offsetYear, Field: "year", Value: y, Err: ErrBadTime}
+	}
+	if n := r.BufferType; n < 0 || n > 15 {
+		return &HeaderError{Offset: offsetFlags, Field: "buffer type", Value: n, Err: ErrBadField}
+	}
+	if n := r.BufferNumber; n < 1 || n > 256 {
+		return &HeaderError{Offset: offsetBuffer, Field: "buffer number", Value: n, Err: ErrBadField}
+	}
+	if n := r.PreEventSeconds; n < 0 || n > 255 {
+		return &HeaderError{Offset: offsetPreEvent, Field: "pre-event seconds", Value: n, Err: ErrBadField}
+	}
+	if n := r.TimeCorrection; n < math.MinInt16 || n > math.MaxInt16 {
+		return &HeaderError{Offset: offsetCorrection, Field: "time correction", Value: n, Err: ErrBadField}
+	}
+	if n := r.TapeNumber; n < 0 || n > 255 {
+		return &HeaderError{Offset: offsetTape, Field: "tape number", Value: n, Err: ErrBadField}
+	}
+	return nil
+}
+
+// decodeYear expands the two digit header year, assuming a 1950 to 2049 range.
+func decodeYear(b byte) int {
+	switch year := int(b); {
+	case year < 50:
+		return year + 2000
+	default:
+		return year + 1900
+	}
+}
+
+// daysIn returns the number of days in the given month.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
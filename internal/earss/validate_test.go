@@ -0,0 +1,118 @@
+package earss
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestValidate_Header(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		offset int
+		value  byte
+		err    error
+	}{
+		"month":      {offsetMonth, 0, ErrBadTime},
+		"day":        {offsetDay, 32, ErrBadTime},
+		"hour":       {offsetHour, 99, ErrBadTime},
+		"year":       {offsetYear, 120, ErrBadTime},
+		"hundredths": {offsetHundredths, 100, ErrBadTime},
+		"channels":   {offsetChannels, 0xb3, ErrBadChannels},
+		"instrument": {offsetInstrument, 0, ErrBadInstrument},
+	}
+
+	for k, v := range tests {
+		t.Run("check "+k, func(t *testing.T) {
+			buf := append([]byte(nil), data...)
+
+			// corrupt the second buffer
+			buf[BufferLength+v.offset] = v.value
+
+			var count int
+			rd := NewReader(bytes.NewReader(buf))
+			for rd.Next() {
+				count++
+			}
+			if count != 1 {
+				t.Errorf("invalid record count before error, expected %d but got %d", 1, count)
+			}
+
+			err := rd.Err()
+			if !errors.Is(err, v.err) {
+				t.Fatalf("expected error %v, got %v", v.err, err)
+			}
+			var berr *BufferError
+			if !errors.As(err, &berr) {
+				t.Fatalf("expected a buffer error, got %T", err)
+			}
+			if berr.Index != 1 {
+				t.Errorf("invalid buffer index, expected %d but got %d", 1, berr.Index)
+			}
+			if n := int64(BufferLength + v.offset); berr.Offset != n {
+				t.Errorf("invalid buffer offset, expected %d but got %d", n, berr.Offset)
+			}
+
+			// the following buffer should still be available
+			if !rd.Next() {
+				t.Fatalf("unable to continue after error: %v", rd.Err())
+			}
+			if n := rd.Record().BufferNumber; n != 3 {
+				t.Errorf("invalid buffer number after error, expected %d but got %d", 3, n)
+			}
+		})
+	}
+}
+
+func TestValidate_ReservedBits(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the undescribed bits of the channel and gain bytes are ignored.
+	buf := append([]byte(nil), data...)
+	buf[BufferLength+offsetChannels] |= 0x0c
+	buf[BufferLength+offsetGains] |= 0xe0
+
+	found, err := Decode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != len(records) || found[1] != records[1] {
+		t.Errorf("reserved bits should not change the decoded records")
+	}
+}
+
+func TestValidate_Record(t *testing.T) {
+
+	data, err := os.ReadFile("testdata/lylm0313.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var record Record
+	if err := record.Decode(data[:BufferLength]); err != nil {
+		t.Fatal(err)
+	}
+	if err := record.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	record.SampleRate = 40
+	if err := record.Validate(); !errors.Is(err, ErrBadSampleRate) {
+		t.Errorf("expected error %v, got %v", ErrBadSampleRate, err)
+	}
+}
@@ -11,6 +11,91 @@ const (
 	Blockette1001Size   = 4
 )
 
+// Blockette is implemented by the data blockettes that may follow the fixed header.
+type Blockette interface {
+	BlocketteType() uint16
+	Marshal() ([]byte, error)
+}
+
+// DecodeBlockette returns the Blockette of the given type from a byte slice, which should hold
+// at least the blockette content, and whether the type is recognised and the slice long enough.
+func DecodeBlockette(kind uint16, data []byte) (Blockette, bool) {
+	size := map[uint16]int{
+		100:  Blockette100Size,
+		200:  Blockette200Size,
+		201:  Blockette201Size,
+		300:  Blockette300Size,
+		310:  Blockette310Size,
+		320:  Blockette320Size,
+		390:  Blockette390Size,
+		395:  Blockette395Size,
+		400:  Blockette400Size,
+		405:  Blockette405Size,
+		500:  Blockette500Size,
+		1000: Blockette1000Size,
+		1001: Blockette1001Size,
+		2000: Blockette2000Size,
+	}
+
+	n, ok := size[kind]
+	if !ok || len(data) < n {
+		return nil, false
+	}
+
+	switch kind {
+	case 100:
+		return DecodeBlockette100(data), true
+	case 200:
+		return DecodeBlockette200(data), true
+	case 201:
+		return DecodeBlockette201(data), true
+	case 300:
+		return DecodeBlockette300(data), true
+	case 310:
+		return DecodeBlockette310(data), true
+	case 320:
+		return DecodeBlockette320(data), true
+	case 390:
+		return DecodeBlockette390(data), true
+	case 395:
+		return DecodeBlockette395(data), true
+	case 400:
+		return DecodeBlockette400(data), true
+	case 405:
+		return DecodeBlockette405(data), true
+	case 500:
+		return DecodeBlockette500(data), true
+	case 1000:
+		return DecodeBlockette1000(data), true
+	case 1001:
+		return DecodeBlockette1001(data), true
+	case 2000:
+		if total := int(binary.BigEndian.Uint16(data[0:2])); total < BlocketteHeaderSize+n || len(data) < total-BlocketteHeaderSize {
+			return nil, false
+		}
+		return DecodeBlockette2000(data), true
+	default:
+		return nil, false
+	}
+}
+
+// BlocketteUnknown holds the raw content of a blockette type that is not otherwise decoded, it is kept
+// so that the blockette can be written back unchanged.
+type BlocketteUnknown struct {
+	Type    uint16
+	Content []byte
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b BlocketteUnknown) BlocketteType() uint16 {
+	return b.Type
+}
+
+// Marshal returns a copy of the raw blockette content.
+func (b BlocketteUnknown) Marshal() ([]byte, error) {
+	return append([]byte(nil), b.Content...), nil
+}
+
 // BlocketteHeader stores the header of each miniseed blockette.
 type BlocketteHeader struct {
 	BlocketteType uint16
@@ -98,6 +183,11 @@ func EncodeBlockette1000(blk Blockette1000) []byte {
 	return d
 }
 
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette1000) BlocketteType() uint16 {
+	return 1000
+}
+
 // Unmarshal converts a byte slice into the Blockette1000
 func (b *Blockette1000) Unmarshal(data []byte) error {
 	*b = DecodeBlockette1000(data)
@@ -154,6 +244,11 @@ func EncodeBlockette1001(blk Blockette1001) []byte {
 	return d
 }
 
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette1001) BlocketteType() uint16 {
+	return 1001
+}
+
 // Unmarshal converts a byte slice into the Blockette1001
 func (b *Blockette1001) Unmarshal(data []byte) error {
 	*b = DecodeBlockette1001(data)
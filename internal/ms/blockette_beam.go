@@ -0,0 +1,125 @@
+package ms
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+const (
+	Blockette400Size = 12
+	Blockette405Size = 2
+)
+
+// Blockette400 is a Beam Blockette (excluding header).
+type Blockette400 struct {
+	BeamAzimuth       float32 // Degrees
+	BeamSlowness      float32 // Seconds per degree
+	BeamConfiguration uint16
+	Reserved          [2]byte
+}
+
+// DecodeBlockette400 returns a Blockette400 from a byte slice.
+func DecodeBlockette400(data []byte) Blockette400 {
+	var b [Blockette400Size]byte
+
+	copy(b[:], data)
+
+	return Blockette400{
+		BeamAzimuth:       math.Float32frombits(binary.BigEndian.Uint32(b[0:4])),
+		BeamSlowness:      math.Float32frombits(binary.BigEndian.Uint32(b[4:8])),
+		BeamConfiguration: binary.BigEndian.Uint16(b[8:10]),
+		Reserved: func() [2]byte {
+			var v [2]byte
+			copy(v[:], b[10:12])
+			return v
+		}(),
+	}
+}
+
+// EncodeBlockette400 converts a Blockette400 into a byte slice.
+func EncodeBlockette400(blk Blockette400) []byte {
+	var b [Blockette400Size]byte
+
+	binary.BigEndian.PutUint32(b[0:4], math.Float32bits(blk.BeamAzimuth))
+	binary.BigEndian.PutUint32(b[4:8], math.Float32bits(blk.BeamSlowness))
+	binary.BigEndian.PutUint16(b[8:10], blk.BeamConfiguration)
+	copy(b[10:12], blk.Reserved[:])
+
+	d := make([]byte, Blockette400Size)
+	copy(d[0:Blockette400Size], b[:])
+
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette400) BlocketteType() uint16 {
+	return 400
+}
+
+// Unmarshal converts a byte slice into the Blockette400
+func (b *Blockette400) Unmarshal(data []byte) error {
+	*b = DecodeBlockette400(data)
+	return nil
+}
+
+// Marshal converts a Blockette400 into a byte slice.
+func (b Blockette400) Marshal() ([]byte, error) {
+	return EncodeBlockette400(b), nil
+}
+
+// Encode writes the Blockette400 into a Writer
+func (b Blockette400) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette400(b)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Blockette405 is a Beam Delay Blockette (excluding header), it holds a delay for each of the
+// beam components, the number of delays is given by the blockette length.
+type Blockette405 struct {
+	DelayValues []uint16 // 0.0001 second units
+}
+
+// DecodeBlockette405 returns a Blockette405 from a byte slice, which should only hold the blockette content.
+func DecodeBlockette405(data []byte) Blockette405 {
+	var blk Blockette405
+	for i := 0; i+Blockette405Size <= len(data); i += Blockette405Size {
+		blk.DelayValues = append(blk.DelayValues, binary.BigEndian.Uint16(data[i:i+Blockette405Size]))
+	}
+	return blk
+}
+
+// EncodeBlockette405 converts a Blockette405 into a byte slice.
+func EncodeBlockette405(blk Blockette405) []byte {
+	d := make([]byte, Blockette405Size*len(blk.DelayValues))
+	for i, v := range blk.DelayValues {
+		binary.BigEndian.PutUint16(d[i*Blockette405Size:], v)
+	}
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette405) BlocketteType() uint16 {
+	return 405
+}
+
+// Unmarshal converts a byte slice into the Blockette405
+func (b *Blockette405) Unmarshal(data []byte) error {
+	*b = DecodeBlockette405(data)
+	return nil
+}
+
+// Marshal converts a Blockette405 into a byte slice.
+func (b Blockette405) Marshal() ([]byte, error) {
+	return EncodeBlockette405(b), nil
+}
+
+// Encode writes the Blockette405 into a Writer
+func (b Blockette405) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette405(b)); err != nil {
+		return err
+	}
+	return nil
+}
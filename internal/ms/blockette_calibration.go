@@ -0,0 +1,434 @@
+package ms
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+const (
+	Blockette300Size = 56
+	Blockette310Size = 56
+	Blockette320Size = 60
+	Blockette390Size = 24
+	Blockette395Size = 12
+)
+
+// Blockette300 is a Step Calibration Blockette (excluding header).
+type Blockette300 struct {
+	BeginningOfCalibration      BTime
+	NumberOfStepCalibrations    uint8
+	CalibrationFlags            uint8
+	StepDuration                uint32 // 0.0001 second units
+	IntervalDuration            uint32 // 0.0001 second units
+	CalibrationSignalAmplitude  float32
+	ChannelWithCalibrationInput [3]byte // ASCII: Left justify and pad with spaces
+	Reserved                    uint8
+	ReferenceAmplitude          uint32
+	Coupling                    [12]byte // ASCII: Left justify and pad with spaces
+	Rolloff                     [12]byte // ASCII: Left justify and pad with spaces
+}
+
+// DecodeBlockette300 returns a Blockette300 from a byte slice.
+func DecodeBlockette300(data []byte) Blockette300 {
+	var b [Blockette300Size]byte
+
+	copy(b[:], data)
+
+	return Blockette300{
+		BeginningOfCalibration:     DecodeBTime(b[0:10]),
+		NumberOfStepCalibrations:   b[10],
+		CalibrationFlags:           b[11],
+		StepDuration:               binary.BigEndian.Uint32(b[12:16]),
+		IntervalDuration:           binary.BigEndian.Uint32(b[16:20]),
+		CalibrationSignalAmplitude: math.Float32frombits(binary.BigEndian.Uint32(b[20:24])),
+		ChannelWithCalibrationInput: func() [3]byte {
+			var v [3]byte
+			copy(v[:], b[24:27])
+			return v
+		}(),
+		Reserved:           b[27],
+		ReferenceAmplitude: binary.BigEndian.Uint32(b[28:32]),
+		Coupling: func() [12]byte {
+			var v [12]byte
+			copy(v[:], b[32:44])
+			return v
+		}(),
+		Rolloff: func() [12]byte {
+			var v [12]byte
+			copy(v[:], b[44:56])
+			return v
+		}(),
+	}
+}
+
+// EncodeBlockette300 converts a Blockette300 into a byte slice.
+func EncodeBlockette300(blk Blockette300) []byte {
+	var b [Blockette300Size]byte
+
+	copy(b[0:10], EncodeBTime(blk.BeginningOfCalibration))
+	b[10] = blk.NumberOfStepCalibrations
+	b[11] = blk.CalibrationFlags
+	binary.BigEndian.PutUint32(b[12:16], blk.StepDuration)
+	binary.BigEndian.PutUint32(b[16:20], blk.IntervalDuration)
+	binary.BigEndian.PutUint32(b[20:24], math.Float32bits(blk.CalibrationSignalAmplitude))
+	copy(b[24:27], blk.ChannelWithCalibrationInput[:])
+	b[27] = blk.Reserved
+	binary.BigEndian.PutUint32(b[28:32], blk.ReferenceAmplitude)
+	copy(b[32:44], blk.Coupling[:])
+	copy(b[44:56], blk.Rolloff[:])
+
+	d := make([]byte, Blockette300Size)
+	copy(d[0:Blockette300Size], b[:])
+
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette300) BlocketteType() uint16 {
+	return 300
+}
+
+// Unmarshal converts a byte slice into the Blockette300
+func (b *Blockette300) Unmarshal(data []byte) error {
+	*b = DecodeBlockette300(data)
+	return nil
+}
+
+// Marshal converts a Blockette300 into a byte slice.
+func (b Blockette300) Marshal() ([]byte, error) {
+	return EncodeBlockette300(b), nil
+}
+
+// Encode writes the Blockette300 into a Writer
+func (b Blockette300) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette300(b)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Blockette310 is a Sine Calibration Blockette (excluding header).
+type Blockette310 struct {
+	BeginningOfCalibration      BTime
+	Reserved                    uint8
+	CalibrationFlags            uint8
+	CalibrationDuration         uint32  // 0.0001 second units
+	PeriodOfSignal              float32 // Seconds
+	AmplitudeOfSignal           float32
+	ChannelWithCalibrationInput [3]byte // ASCII: Left justify and pad with spaces
+	Reserved2                   uint8
+	ReferenceAmplitude          uint32
+	Coupling                    [12]byte // ASCII: Left justify and pad with spaces
+	Rolloff                     [12]byte // ASCII: Left justify and pad with spaces
+}
+
+// DecodeBlockette310 returns a Blockette310 from a byte slice.
+func DecodeBlockette310(data []byte) Blockette310 {
+	var b [Blockette310Size]byte
+
+	copy(b[:], data)
+
+	return Blockette310{
+		BeginningOfCalibration: DecodeBTime(b[0:10]),
+		Reserved:               b[10],
+		CalibrationFlags:       b[11],
+		CalibrationDuration:    binary.BigEndian.Uint32(b[12:16]),
+		PeriodOfSignal:         math.Float32frombits(binary.BigEndian.Uint32(b[16:20])),
+		AmplitudeOfSignal:      math.Float32frombits(binary.BigEndian.Uint32(b[20:24])),
+		ChannelWithCalibrationInput: func() [3]byte {
+			var v [3]byte
+			copy(v[:], b[24:27])
+			return v
+		}(),
+		Reserved2:          b[27],
+		ReferenceAmplitude: binary.BigEndian.Uint32(b[28:32]),
+		Coupling: func() [12]byte {
+			var v [12]byte
+			copy(v[:], b[32:44])
+			return v
+		}(),
+		Rolloff: func() [12]byte {
+			var v [12]byte
+			copy(v[:], b[44:56])
+			return v
+		}(),
+	}
+}
+
+// EncodeBlockette310 converts a Blockette310 into a byte slice.
+func EncodeBlockette310(blk Blockette310) []byte {
+	var b [Blockette310Size]byte
+
+	copy(b[0:10], EncodeBTime(blk.BeginningOfCalibration))
+	b[10] = blk.Reserved
+	b[11] = blk.CalibrationFlags
+	binary.BigEndian.PutUint32(b[12:16], blk.CalibrationDuration)
+	binary.BigEndian.PutUint32(b[16:20], math.Float32bits(blk.PeriodOfSignal))
+	binary.BigEndian.PutUint32(b[20:24], math.Float32bits(blk.AmplitudeOfSignal))
+	copy(b[24:27], blk.ChannelWithCalibrationInput[:])
+	b[27] = blk.Reserved2
+	binary.BigEndian.PutUint32(b[28:32], blk.ReferenceAmplitude)
+	copy(b[32:44], blk.Coupling[:])
+	copy(b[44:56], blk.Rolloff[:])
+
+	d := make([]byte, Blockette310Size)
+	copy(d[0:Blockette310Size], b[:])
+
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette310) BlocketteType() uint16 {
+	return 310
+}
+
+// Unmarshal converts a byte slice into the Blockette310
+func (b *Blockette310) Unmarshal(data []byte) error {
+	*b = DecodeBlockette310(data)
+	return nil
+}
+
+// Marshal converts a Blockette310 into a byte slice.
+func (b Blockette310) Marshal() ([]byte, error) {
+	return EncodeBlockette310(b), nil
+}
+
+// Encode writes the Blockette310 into a Writer
+func (b Blockette310) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette310(b)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Blockette320 is a Pseudo-random Calibration Blockette (excluding header).
+type Blockette320 struct {
+	BeginningOfCalibration      BTime
+	Reserved                    uint8
+	CalibrationFlags            uint8
+	CalibrationDuration         uint32 // 0.0001 second units
+	PeakToPeakAmplitude         float32
+	ChannelWithCalibrationInput [3]byte // ASCII: Left justify and pad with spaces
+	Reserved2                   uint8
+	ReferenceAmplitude          uint32
+	Coupling                    [12]byte // ASCII: Left justify and pad with spaces
+	Rolloff                     [12]byte // ASCII: Left justify and pad with spaces
+	NoiseType                   [8]byte  // ASCII: Left justify and pad with spaces
+}
+
+// DecodeBlockette320 returns a Blockette320 from a byte slice.
+func DecodeBlockette320(data []byte) Blockette320 {
+	var b [Blockette320Size]byte
+
+	copy(b[:], data)
+
+	return Blockette320{
+		BeginningOfCalibration: DecodeBTime(b[0:10]),
+		Reserved:               b[10],
+		CalibrationFlags:       b[11],
+		CalibrationDuration:    binary.BigEndian.Uint32(b[12:16]),
+		PeakToPeakAmplitude:    math.Float32frombits(binary.BigEndian.Uint32(b[16:20])),
+		ChannelWithCalibrationInput: func() [3]byte {
+			var v [3]byte
+			copy(v[:], b[20:23])
+			return v
+		}(),
+		Reserved2:          b[23],
+		ReferenceAmplitude: binary.BigEndian.Uint32(b[24:28]),
+		Coupling: func() [12]byte {
+			var v [12]byte
+			copy(v[:], b[28:40])
+			return v
+		}(),
+		Rolloff: func() [12]byte {
+			var v [12]byte
+			copy(v[:], b[40:52])
+			return v
+		}(),
+		NoiseType: func() [8]byte {
+			var v [8]byte
+			copy(v[:], b[52:60])
+			return v
+		}(),
+	}
+}
+
+// EncodeBlockette320 converts a Blockette320 into a byte slice.
+func EncodeBlockette320(blk Blockette320) []byte {
+	var b [Blockette320Size]byte
+
+	copy(b[0:10], EncodeBTime(blk.BeginningOfCalibration))
+	b[10] = blk.Reserved
+	b[11] = blk.CalibrationFlags
+	binary.BigEndian.PutUint32(b[12:16], blk.CalibrationDuration)
+	binary.BigEndian.PutUint32(b[16:20], math.Float32bits(blk.PeakToPeakAmplitude))
+	copy(b[20:23], blk.ChannelWithCalibrationInput[:])
+	b[23] = blk.Reserved2
+	binary.BigEndian.PutUint32(b[24:28], blk.ReferenceAmplitude)
+	copy(b[28:40], blk.Coupling[:])
+	copy(b[40:52], blk.Rolloff[:])
+	copy(b[52:60], blk.NoiseType[:])
+
+	d := make([]byte, Blockette320Size)
+	copy(d[0:Blockette320Size], b[:])
+
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette320) BlocketteType() uint16 {
+	return 320
+}
+
+// Unmarshal converts a byte slice into the Blockette320
+func (b *Blockette320) Unmarshal(data []byte) error {
+	*b = DecodeBlockette320(data)
+	return nil
+}
+
+// Marshal converts a Blockette320 into a byte slice.
+func (b Blockette320) Marshal() ([]byte, error) {
+	return EncodeBlockette320(b), nil
+}
+
+// Encode writes the Blockette320 into a Writer
+func (b Blockette320) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette320(b)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Blockette390 is a Generic Calibration Blockette (excluding header).
+type Blockette390 struct {
+	BeginningOfCalibration      BTime
+	Reserved                    uint8
+	CalibrationFlags            uint8
+	CalibrationDuration         uint32 // 0.0001 second units
+	CalibrationSignalAmplitude  float32
+	ChannelWithCalibrationInput [3]byte // ASCII: Left justify and pad with spaces
+	Reserved2                   uint8
+}
+
+// DecodeBlockette390 returns a Blockette390 from a byte slice.
+func DecodeBlockette390(data []byte) Blockette390 {
+	var b [Blockette390Size]byte
+
+	copy(b[:], data)
+
+	return Blockette390{
+		BeginningOfCalibration:     DecodeBTime(b[0:10]),
+		Reserved:                   b[10],
+		CalibrationFlags:           b[11],
+		CalibrationDuration:        binary.BigEndian.Uint32(b[12:16]),
+		CalibrationSignalAmplitude: math.Float32frombits(binary.BigEndian.Uint32(b[16:20])),
+		ChannelWithCalibrationInput: func() [3]byte {
+			var v [3]byte
+			copy(v[:], b[20:23])
+			return v
+		}(),
+		Reserved2: b[23],
+	}
+}
+
+// EncodeBlockette390 converts a Blockette390 into a byte slice.
+func EncodeBlockette390(blk Blockette390) []byte {
+	var b [Blockette390Size]byte
+
+	copy(b[0:10], EncodeBTime(blk.BeginningOfCalibration))
+	b[10] = blk.Reserved
+	b[11] = blk.CalibrationFlags
+	binary.BigEndian.PutUint32(b[12:16], blk.CalibrationDuration)
+	binary.BigEndian.PutUint32(b[16:20], math.Float32bits(blk.CalibrationSignalAmplitude))
+	copy(b[20:23], blk.ChannelWithCalibrationInput[:])
+	b[23] = blk.Reserved2
+
+	d := make([]byte, Blockette390Size)
+	copy(d[0:Blockette390Size], b[:])
+
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette390) BlocketteType() uint16 {
+	return 390
+}
+
+// Unmarshal converts a byte slice into the Blockette390
+func (b *Blockette390) Unmarshal(data []byte) error {
+	*b = DecodeBlockette390(data)
+	return nil
+}
+
+// Marshal converts a Blockette390 into a byte slice.
+func (b Blockette390) Marshal() ([]byte, error) {
+	return EncodeBlockette390(b), nil
+}
+
+// Encode writes the Blockette390 into a Writer
+func (b Blockette390) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette390(b)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Blockette395 is a Calibration Abort Blockette (excluding header).
+type Blockette395 struct {
+	EndOfCalibration BTime
+	Reserved         [2]byte
+}
+
+// DecodeBlockette395 returns a Blockette395 from a byte slice.
+func DecodeBlockette395(data []byte) Blockette395 {
+	var b [Blockette395Size]byte
+
+	copy(b[:], data)
+
+	return Blockette395{
+		EndOfCalibration: DecodeBTime(b[0:10]),
+		Reserved: func() [2]byte {
+			var v [2]byte
+			copy(v[:], b[10:12])
+			return v
+		}(),
+	}
+}
+
+// EncodeBlockette395 converts a Blockette395 into a byte slice.
+func EncodeBlockette395(blk Blockette395) []byte {
+	var b [Blockette395Size]byte
+
+	copy(b[0:10], EncodeBTime(blk.EndOfCalibration))
+	copy(b[10:12], blk.Reserved[:])
+
+	d := make([]byte, Blockette395Size)
+	copy(d[0:Blockette395Size], b[:])
+
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette395) BlocketteType() uint16 {
+	return 395
+}
+
+// Unmarshal converts a byte slice into the Blockette395
+func (b *Blockette395) Unmarshal(data []byte) error {
+	*b = DecodeBlockette395(data)
+	return nil
+}
+
+// Marshal converts a Blockette395 into a byte slice.
+func (b Blockette395) Marshal() ([]byte, error) {
+	return EncodeBlockette395(b), nil
+}
+
+// Encode writes the Blockette395 into a Writer
+func (b Blockette395) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette395(b)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package ms
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+const (
+	Blockette200Size = 48
+	Blockette201Size = 56
+)
+
+// Blockette200 is a Generic Event Detection Blockette (excluding header).
+type Blockette200 struct {
+	SignalAmplitude     float32
+	SignalPeriod        float32
+	BackgroundEstimate  float32
+	EventDetectionFlags uint8
+	Reserved            uint8
+	SignalOnsetTime     BTime
+	DetectorName        [24]byte // ASCII: Left justify and pad with spaces
+}
+
+// DecodeBlockette200 returns a Blockette200 from a byte slice.
+func DecodeBlockette200(data []byte) Blockette200 {
+	var b [Blockette200Size]byte
+
+	copy(b[:], data)
+
+	return Blockette200{
+		SignalAmplitude:     math.Float32frombits(binary.BigEndian.Uint32(b[0:4])),
+		SignalPeriod:        math.Float32frombits(binary.BigEndian.Uint32(b[4:8])),
+		BackgroundEstimate:  math.Float32frombits(binary.BigEndian.Uint32(b[8:12])),
+		EventDetectionFlags: b[12],
+		Reserved:            b[13],
+		SignalOnsetTime:     DecodeBTime(b[14:24]),
+		DetectorName: func() [24]byte {
+			var v [24]byte
+			copy(v[:], b[24:48])
+			return v
+		}(),
+	}
+}
+
+// EncodeBlockette200 converts a Blockette200 into a byte slice.
+func EncodeBlockette200(blk Blockette200) []byte {
+	var b [Blockette200Size]byte
+
+	binary.BigEndian.PutUint32(b[0:4], math.Float32bits(blk.SignalAmplitude))
+	binary.BigEndian.PutUint32(b[4:8], math.Float32bits(blk.SignalPeriod))
+	binary.BigEndian.PutUint32(b[8:12], math.Float32bits(blk.BackgroundEstimate))
+	b[12] = blk.EventDetectionFlags
+	b[13] = blk.Reserved
+	copy(b[14:24], EncodeBTime(blk.SignalOnsetTime))
+	copy(b[24:48], blk.DetectorName[:])
+
+	d := make([]byte, Blockette200Size)
+	copy(d[0:Blockette200Size], b[:])
+
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette200) BlocketteType() uint16 {
+	return 200
+}
+
+// Unmarshal converts a byte slice into the Blockette200
+func (b *Blockette200) Unmarshal(data []byte) error {
+	*b = DecodeBlockette200(data)
+	return nil
+}
+
+// Marshal converts a Blockette200 into a byte slice.
+func (b Blockette200) Marshal() ([]byte, error) {
+	return EncodeBlockette200(b), nil
+}
+
+// Encode writes the Blockette200 into a Writer
+func (b Blockette200) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette200(b)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Blockette201 is a Murdock Event Detection Blockette (excluding header).
+type Blockette201 struct {
+	SignalAmplitude     float32
+	SignalPeriod        float32
+	BackgroundEstimate  float32
+	EventDetectionFlags uint8
+	Reserved            uint8
+	SignalOnsetTime     BTime
+	SignalToNoiseRatio  [6]byte // Quality values, only the first five are used
+	LookbackValue       uint8
+	PickAlgorithm       uint8
+	DetectorName        [24]byte // ASCII: Left justify and pad with spaces
+}
+
+// DecodeBlockette201 returns a Blockette201 from a byte slice.
+func DecodeBlockette201(data []byte) Blockette201 {
+	var b [Blockette201Size]byte
+
+	copy(b[:], data)
+
+	return Blockette201{
+		SignalAmplitude:     math.Float32frombits(binary.BigEndian.Uint32(b[0:4])),
+		SignalPeriod:        math.Float32frombits(binary.BigEndian.Uint32(b[4:8])),
+		BackgroundEstimate:  math.Float32frombits(binary.BigEndian.Uint32(b[8:12])),
+		EventDetectionFlags: b[12],
+		Reserved:            b[13],
+		SignalOnsetTime:     DecodeBTime(b[14:24]),
+		SignalToNoiseRatio: func() [6]byte {
+			var v [6]byte
+			copy(v[:], b[24:30])
+			return v
+		}(),
+		LookbackValue: b[30],
+		PickAlgorithm: b[31],
+		DetectorName: func() [24]byte {
+			var v [24]byte
+			copy(v[:], b[32:56])
+			return v
+		}(),
+	}
+}
+
+// EncodeBlockette201 converts a Blockette201 into a byte slice.
+func EncodeBlockette201(blk Blockette201) []byte {
+	var b [Blockette201Size]byte
+
+	binary.BigEndian.PutUint32(b[0:4], math.Float32bits(blk.SignalAmplitude))
+	binary.BigEndian.PutUint32(b[4:8], math.Float32bits(blk.SignalPeriod))
+	binary.BigEndian.PutUint32(b[8:12], math.Float32bits(blk.BackgroundEstimate))
+	b[12] = blk.EventDetectionFlags
+	b[13] = blk.Reserved
+	copy(b[14:24], EncodeBTime(blk.SignalOnsetTime))
+	copy(b[24:30], blk.SignalToNoiseRatio[:])
+	b[30] = blk.LookbackValue
+	b[31] = blk.PickAlgorithm
+	copy(b[32:56], blk.DetectorName[:])
+
+	d := make([]byte, Blockette201Size)
+	copy(d[0:Blockette201Size], b[:])
+
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette201) BlocketteType() uint16 {
+	return 201
+}
+
+// Unmarshal converts a byte slice into the Blockette201
+func (b *Blockette201) Unmarshal(data []byte) error {
+	*b = DecodeBlockette201(data)
+	return nil
+}
+
+// Marshal converts a Blockette201 into a byte slice.
+func (b Blockette201) Marshal() ([]byte, error) {
+	return EncodeBlockette201(b), nil
+}
+
+// Encode writes the Blockette201 into a Writer
+func (b Blockette201) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette201(b)); err != nil {
+		return err
+	}
+	return nil
+}
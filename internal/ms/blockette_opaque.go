@@ -0,0 +1,123 @@
+package ms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// Blockette2000Size is the fixed length of an encoded Blockette2000, excluding the header and variable fields.
+const Blockette2000Size = 11
+
+// Blockette2000 is a Variable Length Opaque Data Blockette (excluding header).
+type Blockette2000 struct {
+	RecordNumber uint32
+	WordOrder    uint8
+	DataFlags    uint8
+	HeaderFields []string // ASCII: Each field is terminated by a tilde
+	Data         []byte
+}
+
+// Size returns the total encoded length of the blockette, including the header.
+func (b Blockette2000) Size() int {
+	n := BlocketteHeaderSize + Blockette2000Size + len(b.Data)
+	for _, f := range b.HeaderFields {
+		n += len(f) + 1
+	}
+	return n
+}
+
+// DecodeBlockette2000 returns a Blockette2000 from a byte slice, the total blockette length
+// and the offset to the opaque data are taken from the encoded values.
+func DecodeBlockette2000(data []byte) Blockette2000 {
+	var b [Blockette2000Size]byte
+
+	copy(b[:], data)
+
+	total := int(binary.BigEndian.Uint16(b[0:2])) - BlocketteHeaderSize
+	offset := int(binary.BigEndian.Uint16(b[2:4])) - BlocketteHeaderSize
+
+	blk := Blockette2000{
+		RecordNumber: binary.BigEndian.Uint32(b[4:8]),
+		WordOrder:    b[8],
+		DataFlags:    b[9],
+	}
+
+	if offset > len(data) {
+		offset = len(data)
+	}
+	if total > len(data) {
+		total = len(data)
+	}
+
+	if offset > Blockette2000Size {
+		fields := data[Blockette2000Size:offset]
+		for i := 0; i < int(b[10]) && len(fields) > 0; i++ {
+			n := bytes.IndexByte(fields, '~')
+			if n < 0 {
+				n = len(fields)
+			}
+			blk.HeaderFields = append(blk.HeaderFields, string(fields[:n]))
+			if fields = fields[n:]; len(fields) > 0 {
+				fields = fields[1:]
+			}
+		}
+	}
+
+	if total > offset && offset >= Blockette2000Size {
+		blk.Data = make([]byte, total-offset)
+		copy(blk.Data, data[offset:total])
+	}
+
+	return blk
+}
+
+// EncodeBlockette2000 converts a Blockette2000 into a byte slice.
+func EncodeBlockette2000(blk Blockette2000) []byte {
+	var b [Blockette2000Size]byte
+
+	var fields []byte
+	for _, f := range blk.HeaderFields {
+		fields = append(append(fields, f...), '~')
+	}
+
+	offset := BlocketteHeaderSize + Blockette2000Size + len(fields)
+
+	binary.BigEndian.PutUint16(b[0:2], uint16(offset+len(blk.Data)))
+	binary.BigEndian.PutUint16(b[2:4], uint16(offset))
+	binary.BigEndian.PutUint32(b[4:8], blk.RecordNumber)
+	b[8] = blk.WordOrder
+	b[9] = blk.DataFlags
+	b[10] = uint8(len(blk.HeaderFields))
+
+	d := make([]byte, 0, blk.Size()-BlocketteHeaderSize)
+	d = append(d, b[:]...)
+	d = append(d, fields...)
+	d = append(d, blk.Data...)
+
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette2000) BlocketteType() uint16 {
+	return 2000
+}
+
+// Unmarshal converts a byte slice into the Blockette2000
+func (b *Blockette2000) Unmarshal(data []byte) error {
+	*b = DecodeBlockette2000(data)
+	return nil
+}
+
+// Marshal converts a Blockette2000 into a byte slice.
+func (b Blockette2000) Marshal() ([]byte, error) {
+	return EncodeBlockette2000(b), nil
+}
+
+// Encode writes the Blockette2000 into a Writer
+func (b Blockette2000) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette2000(b)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package ms
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// Blockette100Size is the fixed length of an encoded Blockette100, excluding the header.
+const Blockette100Size = 8
+
+// Blockette100 is a Sample Rate Blockette (excluding header).
+type Blockette100 struct {
+	SampleRate float32 // Actual sample rate
+	Flags      uint8
+	Reserved   [3]byte
+}
+
+// DecodeBlockette100 returns a Blockette100 from a byte slice.
+func DecodeBlockette100(data []byte) Blockette100 {
+	var b [Blockette100Size]byte
+
+	copy(b[:], data)
+
+	return Blockette100{
+		SampleRate: math.Float32frombits(binary.BigEndian.Uint32(b[0:4])),
+		Flags:      b[4],
+		Reserved: func() [3]byte {
+			var v [3]byte
+			copy(v[:], b[5:8])
+			return v
+		}(),
+	}
+}
+
+// EncodeBlockette100 converts a Blockette100 into a byte slice.
+func EncodeBlockette100(blk Blockette100) []byte {
+	var b [Blockette100Size]byte
+
+	binary.BigEndian.PutUint32(b[0:4], math.Float32bits(blk.SampleRate))
+	b[4] = blk.Flags
+	copy(b[5:8], blk.Reserved[:])
+
+	d := make([]byte, Blockette100Size)
+	copy(d[0:Blockette100Size], b[:])
+
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette100) BlocketteType() uint16 {
+	return 100
+}
+
+// Unmarshal converts a byte slice into the Blockette100
+func (b *Blockette100) Unmarshal(data []byte) error {
+	*b = DecodeBlockette100(data)
+	return nil
+}
+
+// Marshal converts a Blockette100 into a byte slice.
+func (b Blockette100) Marshal() ([]byte, error) {
+	return EncodeBlockette100(b), nil
+}
+
+// Encode writes the Blockette100 into a Writer
+func (b Blockette100) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette100(b)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package ms
 
 import (
+	"bytes"
+	"fmt"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestBlockette_Header(t *testing.T) {
@@ -168,3 +172,195 @@ func TestBlockette_1001(t *testing.T) {
 		}
 	})
 }
+
+func TestBlockette_Standard(t *testing.T) {
+	onset := NewBTime(time.Date(2023, 4, 5, 6, 7, 8, 900000000, time.UTC))
+
+	var detector [24]byte
+	copy(detector[:], "Z_SPWWSS                ")
+
+	var clock [32]byte
+	copy(clock[:], "GPS                             ")
+
+	blockettes := []Blockette{
+		Blockette100{SampleRate: 99.9987},
+		Blockette200{SignalAmplitude: 1234.5, SignalPeriod: 0.25, BackgroundEstimate: 12, EventDetectionFlags: 4, SignalOnsetTime: onset, DetectorName: detector},
+		Blockette201{SignalAmplitude: 1234.5, SignalPeriod: 0.25, BackgroundEstimate: 12, SignalOnsetTime: onset, SignalToNoiseRatio: [6]byte{1, 2, 3, 4, 5, 0}, LookbackValue: 2, PickAlgorithm: 1, DetectorName: detector},
+		Blockette300{BeginningOfCalibration: onset, NumberOfStepCalibrations: 1, StepDuration: 10000, IntervalDuration: 20000, CalibrationSignalAmplitude: -1.5, ChannelWithCalibrationInput: [3]byte{'E', 'H', 'Z'}},
+		Blockette310{BeginningOfCalibration: onset, CalibrationDuration: 600000, PeriodOfSignal: 1, AmplitudeOfSignal: 2},
+		Blockette320{BeginningOfCalibration: onset, CalibrationDuration: 600000, PeakToPeakAmplitude: 3, NoiseType: [8]byte{'R', 'A', 'N', 'D', 'O', 'M', ' ', ' '}},
+		Blockette390{BeginningOfCalibration: onset, CalibrationDuration: 600000, CalibrationSignalAmplitude: 4},
+		Blockette395{EndOfCalibration: onset},
+		Blockette400{BeamAzimuth: 123.5, BeamSlowness: 0.125, BeamConfiguration: 7},
+		Blockette405{DelayValues: []uint16{25, 50, 75}},
+		Blockette500{VCOCorrection: 50.5, TimeOfException: onset, MicroSec: -12, ReceptionQuality: 90, ExceptionCount: 3, ClockModel: clock},
+		Blockette2000{RecordNumber: 17, WordOrder: 1, DataFlags: 2, HeaderFields: []string{"EARSS", "106"}, Data: []byte("opaque")},
+	}
+
+	for _, raw := range blockettes {
+		t.Run(fmt.Sprintf("blockette %d", raw.BlocketteType()), func(t *testing.T) {
+			data, err := raw.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, ok := DecodeBlockette(raw.BlocketteType(), data)
+			if !ok {
+				t.Fatalf("unable to decode blockette %d", raw.BlocketteType())
+			}
+
+			if !reflect.DeepEqual(raw, res) {
+				t.Errorf("marshal/decode error, expected %v but got %v", raw, res)
+			}
+		})
+	}
+
+	t.Run("record", func(t *testing.T) {
+		rec := NewEmptyRecord(12, 100, 1)
+		rec.SetStation("LYLM")
+		rec.SetChannel("EHZ")
+		rec.RecordStartTime = onset
+		for _, b := range blockettes {
+			rec.AddBlockette(b)
+		}
+
+		var records []*Record
+		if err := rec.PackInt32(onset.Time(), make([]int32, 10), func(r *Record) error {
+			records = append(records, r)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("invalid number of records, expected %d got %d", 1, len(records))
+		}
+
+		data, err := records[0].Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != 4096 {
+			t.Fatalf("invalid record length, expected %d got %d", 4096, len(data))
+		}
+
+		res, err := NewRecord(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(blockettes, res.Blockettes) {
+			t.Errorf("invalid blockettes, expected %v got %v", blockettes, res.Blockettes)
+		}
+		if res.B1000 != records[0].B1000 {
+			t.Errorf("invalid blockette 1000, expected %v got %v", records[0].B1000, res.B1000)
+		}
+
+		check, err := res.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, check) {
+			t.Error("marshal/unpack/marshal did not round trip")
+		}
+
+		b100, ok := res.Blockette(100)
+		if !ok || b100.(Blockette100).SampleRate != 99.9987 {
+			t.Errorf("invalid blockette 100, got %v", b100)
+		}
+	})
+}
+
+func TestBlockette_Layout(t *testing.T) {
+
+	samples := []int32{1, -2, 3, -4, 5, -6, 7, -8, 9, -10}
+
+	rec := NewEmptyRecord(8, 100, 1)
+	rec.SetStation("LYLM")
+	rec.SetChannel("EHZ")
+	rec.BeginningOfData = 128
+
+	var packed *Record
+	if err := rec.PackInt32(time.Date(1994, 3, 12, 23, 9, 58, 650000000, time.UTC), samples, func(r *Record) error {
+		packed = r
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := packed.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// replace the blockettes with a blockette 100, an unknown blockette and then the blockette 1000.
+	content := []byte{1, 2, 3, 4, 5, 6}
+	blockettes := bytes.Join([][]byte{
+		EncodeBlocketteHeader(BlocketteHeader{BlocketteType: 100, NextBlockette: 60}),
+		EncodeBlockette100(Blockette100{SampleRate: 99.5}),
+		EncodeBlocketteHeader(BlocketteHeader{BlocketteType: 999, NextBlockette: 70}),
+		content,
+		EncodeBlocketteHeader(BlocketteHeader{BlocketteType: 1000}),
+		EncodeBlockette1000(packed.B1000),
+	}, nil)
+
+	copy(raw[48:128], make([]byte, 80))
+	copy(raw[48:], blockettes)
+	raw[39] = 3
+
+	res, err := NewRecord(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.HasBlockette1001() {
+		t.Error("unexpected blockette 1001")
+	}
+	expected := []Blockette{
+		Blockette100{SampleRate: 99.5},
+		BlocketteUnknown{Type: 999, Content: content},
+	}
+	if !reflect.DeepEqual(expected, res.Blockettes) {
+		t.Errorf("invalid blockettes, expected %v got %v", expected, res.Blockettes)
+	}
+	if res.B1000 != packed.B1000 {
+		t.Errorf("invalid blockette 1000, expected %v got %v", packed.B1000, res.B1000)
+	}
+
+	values, err := res.Int32s()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, samples) {
+		t.Errorf("invalid samples, expected %v got %v", samples, values)
+	}
+
+	check, err := res.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, check) {
+		t.Error("unpack/marshal did not round trip")
+	}
+
+	// an added blockette follows the existing blockettes.
+	res.AddBlockette(Blockette405{DelayValues: []uint16{25, 50}})
+	data, err := res.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := NewRecord(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.HasBlockette1001() || len(again.Blockettes) != 3 || again.Blockettes[2].BlocketteType() != 405 {
+		t.Errorf("invalid blockettes after adding, got %v", again.Blockettes)
+	}
+	// the last blockette runs up to the data, so any padding is read as trailing beam delays.
+	if b405, ok := again.Blockette(405); !ok || len(b405.(Blockette405).DelayValues) < 2 ||
+		!reflect.DeepEqual(b405.(Blockette405).DelayValues[:2], []uint16{25, 50}) {
+		t.Errorf("invalid beam delays, expected %v got %v", []uint16{25, 50}, b405)
+	}
+	// only the next blockette pointer of the blockette 1000 should change.
+	if !bytes.Equal(data[48:72], raw[48:72]) || !bytes.Equal(data[74:78], raw[74:78]) {
+		t.Error("existing blockettes were not kept in order")
+	}
+}
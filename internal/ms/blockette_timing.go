@@ -0,0 +1,95 @@
+package ms
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// Blockette500Size is the fixed length of an encoded Blockette500, excluding the header.
+const Blockette500Size = 196
+
+// Blockette500 is a Timing Blockette (excluding header).
+type Blockette500 struct {
+	VCOCorrection    float32 // Percentage of the VCO control value
+	TimeOfException  BTime
+	MicroSec         int8 // Increased accuracy for the exception time
+	ReceptionQuality uint8
+	ExceptionCount   uint32
+	ExceptionType    [16]byte  // ASCII: Left justify and pad with spaces
+	ClockModel       [32]byte  // ASCII: Left justify and pad with spaces
+	ClockStatus      [128]byte // ASCII: Left justify and pad with spaces
+}
+
+// DecodeBlockette500 returns a Blockette500 from a byte slice.
+func DecodeBlockette500(data []byte) Blockette500 {
+	var b [Blockette500Size]byte
+
+	copy(b[:], data)
+
+	return Blockette500{
+		VCOCorrection:    math.Float32frombits(binary.BigEndian.Uint32(b[0:4])),
+		TimeOfException:  DecodeBTime(b[4:14]),
+		MicroSec:         int8(b[14]),
+		ReceptionQuality: b[15],
+		ExceptionCount:   binary.BigEndian.Uint32(b[16:20]),
+		ExceptionType: func() [16]byte {
+			var v [16]byte
+			copy(v[:], b[20:36])
+			return v
+		}(),
+		ClockModel: func() [32]byte {
+			var v [32]byte
+			copy(v[:], b[36:68])
+			return v
+		}(),
+		ClockStatus: func() [128]byte {
+			var v [128]byte
+			copy(v[:], b[68:196])
+			return v
+		}(),
+	}
+}
+
+// EncodeBlockette500 converts a Blockette500 into a byte slice.
+func EncodeBlockette500(blk Blockette500) []byte {
+	var b [Blockette500Size]byte
+
+	binary.BigEndian.PutUint32(b[0:4], math.Float32bits(blk.VCOCorrection))
+	copy(b[4:14], EncodeBTime(blk.TimeOfException))
+	b[14] = uint8(blk.MicroSec)
+	b[15] = blk.ReceptionQuality
+	binary.BigEndian.PutUint32(b[16:20], blk.ExceptionCount)
+	copy(b[20:36], blk.ExceptionType[:])
+	copy(b[36:68], blk.ClockModel[:])
+	copy(b[68:196], blk.ClockStatus[:])
+
+	d := make([]byte, Blockette500Size)
+	copy(d[0:Blockette500Size], b[:])
+
+	return d
+}
+
+// BlocketteType returns the SEED blockette type number.
+func (b Blockette500) BlocketteType() uint16 {
+	return 500
+}
+
+// Unmarshal converts a byte slice into the Blockette500
+func (b *Blockette500) Unmarshal(data []byte) error {
+	*b = DecodeBlockette500(data)
+	return nil
+}
+
+// Marshal converts a Blockette500 into a byte slice.
+func (b Blockette500) Marshal() ([]byte, error) {
+	return EncodeBlockette500(b), nil
+}
+
+// Encode writes the Blockette500 into a Writer
+func (b Blockette500) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeBlockette500(b)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -12,7 +12,7 @@ func decodeInt32(data []byte, order uint8, samples uint16) ([]int32, error) {
 	}
 
 	var values []int32
-	for i := 0; i < int(samples*4); i += 4 {
+	for i := 0; i < int(samples)*4; i += 4 {
 		var b uint32
 		switch order {
 		case 0:
@@ -31,7 +31,7 @@ func decodeFloat32(data []byte, order uint8, samples uint16) ([]float32, error)
 		return nil, fmt.Errorf("invalid data length: %d", n)
 	}
 	var values []float32
-	for i := 0; i < int(samples*4); i += 4 {
+	for i := 0; i < int(samples)*4; i += 4 {
 		var b uint32
 		switch order {
 		case 0:
@@ -49,7 +49,7 @@ func decodeFloat64(data []byte, order uint8, samples uint16) ([]float64, error)
 		return nil, fmt.Errorf("invalid data length: %d", n)
 	}
 	var values []float64
-	for i := 0; i < int(samples*8); i += 8 {
+	for i := 0; i < int(samples)*8; i += 8 {
 		var b uint64
 		switch order {
 		case 0:
@@ -0,0 +1,111 @@
+package ms
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// Conflict describes a record that shares its header Hash with an earlier record but holds different data.
+type Conflict struct {
+	Key     string
+	SrcName string
+	Start   time.Time
+	Samples int
+}
+
+// String implements the Stringer interface.
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s %s %d samples (%s)", c.SrcName, c.Start.Format("2006,002,15:04:05.000000"), c.Samples, c.Key)
+}
+
+// Dedup drops repeated records, as can be found in tapes that have been converted twice or in concatenated
+// files. Records are identified by their header Hash, the first record seen for a key is kept and any later
+// record with the same key is dropped. If a dropped record has different data it is reported as a Conflict.
+type Dedup struct {
+	seen      map[string]uint64
+	dropped   int
+	conflicts []Conflict
+}
+
+// NewDedup returns an empty Dedup filter.
+func NewDedup() *Dedup {
+	return &Dedup{
+		seen: make(map[string]uint64),
+	}
+}
+
+// dataHash returns a stable hash of the record samples, decoding them where possible so that any padding or
+// unused steim frames are ignored. The raw data is used for records that can not be decoded.
+func dataHash(msr *Record) uint64 {
+	hm := fnv.New64a()
+
+	var buf [8]byte
+	switch Encoding(msr.B1000.Encoding) {
+	case EncodingInt32, EncodingSTEIM1, EncodingSTEIM2:
+		if samples, err := msr.Int32s(); err == nil {
+			for _, v := range samples {
+				binary.BigEndian.PutUint32(buf[:4], uint32(v))
+				hm.Write(buf[:4])
+			}
+			return hm.Sum64()
+		}
+	case EncodingIEEEFloat, EncodingIEEEDouble:
+		if samples, err := msr.Float64s(); err == nil {
+			for _, v := range samples {
+				binary.BigEndian.PutUint64(buf[:], math.Float64bits(v))
+				hm.Write(buf[:])
+			}
+			return hm.Sum64()
+		}
+	}
+
+	hm.Write(msr.Data)
+
+	return hm.Sum64()
+}
+
+// Keep returns whether the record has not been seen before and should be kept.
+func (d *Dedup) Keep(msr *Record) bool {
+	key, sum := msr.Hash(), dataHash(msr)
+
+	prev, ok := d.seen[key]
+	if !ok {
+		d.seen[key] = sum
+		return true
+	}
+
+	if prev != sum {
+		d.conflicts = append(d.conflicts, Conflict{
+			Key:     key,
+			SrcName: msr.SrcName(true),
+			Start:   msr.StartTime(),
+			Samples: msr.SampleCount(),
+		})
+	}
+	d.dropped++
+
+	return false
+}
+
+// Filter wraps a RecordFunc so that only records which should be kept are passed on.
+func (d *Dedup) Filter(fn RecordFunc) RecordFunc {
+	return func(msr *Record) error {
+		if !d.Keep(msr) {
+			return nil
+		}
+		return fn(msr)
+	}
+}
+
+// Dropped returns the number of records that have been dropped, including any conflicts.
+func (d *Dedup) Dropped() int {
+	return d.dropped
+}
+
+// Conflicts returns the dropped records that had different data to the record that was kept.
+func (d *Dedup) Conflicts() []Conflict {
+	return d.conflicts
+}
@@ -0,0 +1,143 @@
+package ms
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDedup_Hash(t *testing.T) {
+	raw, err := os.ReadFile("testdata/basic.mseed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msr, err := NewRecord(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the hash must be stable between runs
+	if h := msr.RecordHeader.Hash(); h != "0xc03961cefd9536a1" {
+		t.Errorf("invalid header hash, got %s", h)
+	}
+	if h := msr.Hash(); h != "0x36b7f3b8e48c09f3" {
+		t.Errorf("invalid hash, got %s", h)
+	}
+
+	other := *msr
+	other.SetSeqNumber(1)
+	if other.Hash() != msr.Hash() {
+		t.Errorf("sequence numbers should not change the hash")
+	}
+	other.SetStation("OTHER")
+	if other.Hash() == msr.Hash() {
+		t.Errorf("station codes should change the hash")
+	}
+
+	precise := *msr
+	precise.B1001.MicroSec++
+	if precise.Hash() == msr.Hash() {
+		t.Errorf("blockette 1001 microseconds should change the hash")
+	}
+	precise.B1001.MicroSec = 0
+	if precise.Hash() != msr.RecordHeader.Hash() {
+		t.Errorf("a zero microsecond offset should match the header hash")
+	}
+}
+
+func TestDedup_Keep(t *testing.T) {
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	pack := func(value int32) []*Record {
+		rec := NewEmptyRecord(9, 100, 1)
+		rec.SetNetwork("NZ")
+		rec.SetStation("WEL")
+		rec.SetChannel("HHZ")
+
+		samples := make([]int32, 224)
+		for i := range samples {
+			samples[i] = value
+		}
+
+		var records []*Record
+		if err := rec.PackInt32(start, samples, func(r *Record) error {
+			records = append(records, r)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return records
+	}
+
+	first, second, conflict := pack(1), pack(1), pack(2)
+
+	dedup := NewDedup()
+
+	var kept []*Record
+	keep := dedup.Filter(func(r *Record) error {
+		kept = append(kept, r)
+		return nil
+	})
+	for _, list := range [][]*Record{first, second, conflict} {
+		for _, r := range list {
+			if err := keep(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if len(kept) != len(first) {
+		t.Errorf("invalid kept records, expected %d got %d", len(first), len(kept))
+	}
+	if n := dedup.Dropped(); n != len(second)+len(conflict) {
+		t.Errorf("invalid dropped records, expected %d got %d", len(second)+len(conflict), n)
+	}
+	if n := len(dedup.Conflicts()); n != len(conflict) {
+		t.Errorf("invalid conflicts, expected %d got %d", len(conflict), n)
+	}
+}
+
+func TestDedup_DataHash(t *testing.T) {
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	pack := func(samples []int32) *Record {
+		rec := NewEmptyRecord(9, 100, 1)
+		rec.SetNetwork("NZ")
+		rec.SetStation("WEL")
+		rec.SetChannel("HHZ")
+
+		var records []*Record
+		if err := rec.PackSteim2(start, 0, samples, func(r *Record) error {
+			records = append(records, r)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("invalid number of records, expected %d got %d", 1, len(records))
+		}
+		return records[0]
+	}
+
+	samples := []int32{1, 2, 3, 4, 5, 6, 7, 8}
+
+	msr := pack(samples)
+	if n := int(msr.B1001.FrameCount) * 64; n >= len(msr.Data) {
+		t.Fatalf("expected unused frames, got %d of %d bytes", n, len(msr.Data))
+	}
+
+	// anything after the used frames is not part of the samples.
+	padded := *msr
+	padded.Data = append([]byte(nil), msr.Data...)
+	for i := int(msr.B1001.FrameCount) * 64; i < len(padded.Data); i++ {
+		padded.Data[i] = 0xff
+	}
+	if dataHash(&padded) != dataHash(msr) {
+		t.Errorf("unused frames should not change the data hash")
+	}
+
+	samples[len(samples)-1]++
+	if dataHash(pack(samples)) == dataHash(msr) {
+		t.Errorf("different samples should change the data hash")
+	}
+}
@@ -0,0 +1,93 @@
+package ms
+
+import (
+	"sort"
+	"time"
+)
+
+// Gap describes a break between consecutive records of a stream, a negative duration
+// indicates that the records overlap.
+type Gap struct {
+	SrcName string
+	End     time.Time     // the time of the last sample before the break
+	Start   time.Time     // the time of the first sample after the break
+	Period  time.Duration // the stream sample period
+}
+
+// Expected returns the time the first sample after the break should have had.
+func (g Gap) Expected() time.Time {
+	return g.End.Add(g.Period)
+}
+
+// Duration returns the length of the break, a negative value is an overlap.
+func (g Gap) Duration() time.Duration {
+	return g.Start.Sub(g.Expected())
+}
+
+// IsOverlap returns whether the break is an overlap rather than missing data.
+func (g Gap) IsOverlap() bool {
+	return g.Duration() < 0
+}
+
+// Samples returns the approximate number of missing, or overlapping, samples.
+func (g Gap) Samples() int {
+	if !(g.Period > 0) {
+		return 0
+	}
+	d := g.Duration()
+	if d < 0 {
+		d = -d
+	}
+	return int((d + g.Period/2) / g.Period)
+}
+
+// FindGaps sorts a copy of the records using RecordHeader.Less and compares the start time of each
+// record with the latest end time of the previous records in the same stream, as given by SrcName including
+// the quality indicator. Any difference from the expected time greater than the tolerance is returned
+// as a Gap, a zero tolerance will use half of the sample period. The actual sample rate is used
+// for the record end times if it is given in a Blockette 100.
+func FindGaps(records []Record, tolerance time.Duration) []Gap {
+
+	list := make([]Record, len(records))
+	copy(list, records)
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].RecordHeader.Less(list[j].RecordHeader)
+	})
+
+	var gaps []Gap
+	var end time.Time
+	for i, next := range list {
+		switch {
+		case i == 0, !(next.SampleRate() > 0):
+			end = next.EndTime()
+			continue
+		case list[i-1].SrcName(true) != next.SrcName(true), list[i-1].SampleRate() != next.SampleRate():
+			end = next.EndTime()
+			continue
+		}
+
+		gap := Gap{
+			SrcName: next.SrcName(false),
+			End:     end,
+			Start:   next.StartTime(),
+			Period:  next.SamplePeriod(),
+		}
+
+		limit := tolerance
+		if !(limit > 0) {
+			limit = gap.Period / 2
+		}
+
+		if d := gap.Duration(); d > limit || d < -limit {
+			gaps = append(gaps, gap)
+		}
+
+		// a record may be completely covered by an earlier one
+		if t := next.EndTime(); t.After(end) {
+			end = t
+		}
+	}
+
+	return gaps
+}
@@ -0,0 +1,122 @@
+package ms
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGaps_FindGaps(t *testing.T) {
+
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	pack := func(station string, at time.Time, count int) []Record {
+		rec := NewEmptyRecord(9, 100, 1)
+		rec.SetNetwork("NZ")
+		rec.SetStation(station)
+		rec.SetChannel("HHZ")
+
+		var records []Record
+		if err := rec.PackInt32(at, make([]int32, count), func(r *Record) error {
+			records = append(records, *r)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return records
+	}
+
+	t.Run("contiguous", func(t *testing.T) {
+		records := pack("WEL", start, 1000)
+		if gaps := FindGaps(records, 0); len(gaps) != 0 {
+			t.Errorf("invalid gaps, expected none got %v", gaps)
+		}
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		records := pack("WEL", start, 1000)
+		// remove the second record, out of order to check sorting
+		records = append(records[2:], records[0])
+
+		gaps := FindGaps(records, 0)
+		if len(gaps) != 1 {
+			t.Fatalf("invalid gaps, expected %d got %d", 1, len(gaps))
+		}
+		if gaps[0].IsOverlap() {
+			t.Errorf("expected a gap not an overlap")
+		}
+		// each record holds 112 samples
+		if n := gaps[0].Samples(); n != 112 {
+			t.Errorf("invalid missing samples, expected %d got %d", 112, n)
+		}
+		if d := gaps[0].Duration(); d != 1120*time.Millisecond {
+			t.Errorf("invalid gap duration, expected %v got %v", 1120*time.Millisecond, d)
+		}
+	})
+
+	t.Run("overlap", func(t *testing.T) {
+		records := append(pack("WEL", start, 112), pack("WEL", start.Add(500*time.Millisecond), 112)...)
+
+		gaps := FindGaps(records, 0)
+		if len(gaps) != 1 {
+			t.Fatalf("invalid overlaps, expected %d got %d", 1, len(gaps))
+		}
+		if !gaps[0].IsOverlap() {
+			t.Errorf("expected an overlap not a gap")
+		}
+		if d := gaps[0].Duration(); d != -620*time.Millisecond {
+			t.Errorf("invalid overlap duration, expected %v got %v", -620*time.Millisecond, d)
+		}
+	})
+
+	t.Run("tolerance", func(t *testing.T) {
+		records := append(pack("WEL", start, 100), pack("WEL", start.Add(1020*time.Millisecond), 100)...)
+		if gaps := FindGaps(records, 0); len(gaps) != 1 {
+			t.Errorf("invalid gaps, expected %d got %d", 1, len(gaps))
+		}
+		if gaps := FindGaps(records, 50*time.Millisecond); len(gaps) != 0 {
+			t.Errorf("invalid gaps, expected none got %v", gaps)
+		}
+	})
+
+	t.Run("actual rate", func(t *testing.T) {
+		rate := 101.0
+		period := time.Duration(float64(time.Second) / rate)
+
+		var records []Record
+		for i := 0; i < 10; i++ {
+			rec := NewEmptyRecord(9, 100, 1)
+			rec.SetNetwork("NZ")
+			rec.SetStation("WEL")
+			rec.SetChannel("HHZ")
+			rec.SetActualSampleRate(rate)
+
+			// the extra blockette leaves room for 96 samples
+			at := start.Add(time.Duration(i*96) * period)
+			if err := rec.PackInt32(at, make([]int32, 96), func(r *Record) error {
+				records = append(records, *r)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if gaps := FindGaps(records, 0); len(gaps) != 0 {
+			t.Errorf("invalid gaps, expected none got %v", gaps)
+		}
+
+		// without the blockette the nominal rate builds up timing errors
+		for i := range records {
+			records[i].Blockettes = nil
+			records[i].NumberOfBlockettesThatFollow--
+		}
+		if gaps := FindGaps(records, 0); len(gaps) != len(records)-1 {
+			t.Errorf("invalid overlaps, expected %d got %d", len(records)-1, len(gaps))
+		}
+	})
+
+	t.Run("streams", func(t *testing.T) {
+		records := append(pack("WEL", start, 100), pack("TDHS", start.Add(time.Second), 100)...)
+		if gaps := FindGaps(records, 0); len(gaps) != 0 {
+			t.Errorf("invalid gaps, expected none got %v", gaps)
+		}
+	})
+}
@@ -3,7 +3,7 @@ package ms
 import (
 	"encoding/binary"
 	"fmt"
-	//TODO: needs v 1.15 "hash/maphash"
+	"hash/fnv"
 	"io"
 	"strconv"
 	"strings"
@@ -164,11 +164,16 @@ func (h RecordHeader) IsValid() bool {
 	return true
 }
 
-//TODO: needs v1.15
-/*
-// Hash can be used for deduping record blocks
+// Hash can be used for deduping record blocks, it is built from the stream codes, quality,
+// start time, number of samples and sample rate factors using a stable FNV-1a hash.
 func (h RecordHeader) Hash() string {
-	var hm maphash.Hash
+	return h.hash(0)
+}
+
+// hash builds the header hash, a non-zero microsecond offset from a blockette 1001 is included so
+// records that only differ in their precise start time are kept apart.
+func (h RecordHeader) hash(microsec int8) string {
+	hm := fnv.New64a()
 
 	hm.Write(h.NetworkIdentifier[:])
 	hm.Write(h.StationIdentifier[:])
@@ -183,9 +188,12 @@ func (h RecordHeader) Hash() string {
 	binary.BigEndian.PutUint16(parts[4:6], uint16(h.SampleRateMultiplier))
 	hm.Write(parts[:])
 
+	if microsec != 0 {
+		hm.Write([]byte{uint8(microsec)})
+	}
+
 	return fmt.Sprintf("%#x", hm.Sum64())
 }
-*/
 
 // Less can be used for sorting record blocks.
 func (h RecordHeader) Less(hdr RecordHeader) bool {
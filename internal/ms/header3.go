@@ -0,0 +1,197 @@
+package ms
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// RecordHeader3Size is the miniseed 3 fixed header length.
+const RecordHeader3Size = 40
+
+// FormatVersion3 is the miniseed 3 format version.
+const FormatVersion3 = 3
+
+// RecordHeader3 is the fixed section of a miniseed 3 record, unlike miniseed 2 all values are little endian.
+type RecordHeader3 struct {
+	RecordIndicator [2]byte // ASCII: MS
+	FormatVersion   uint8   // Always 3
+
+	Flags byte // Bit 0: calibration signals present, 1: time tag questionable, 2: clock locked
+
+	Nanosecond uint32 // Start time of record
+	Year       uint16
+	DayOfYear  uint16
+	Hour       uint8
+	Minute     uint8
+	Second     uint8
+
+	Encoding        uint8   // Data payload encoding
+	SampleRate      float64 // >0: Samples/Second <0: -Seconds/Sample 0: no time series data
+	NumberOfSamples uint32  // Number of Samples in the data payload
+	CRC             uint32  // CRC-32C of the record with this field set to zero
+
+	PublicationVersion uint8 // 1: Raw, 2: Data, 3: Quality controlled, 4: Modified
+
+	SourceIdentifierLength uint8  // Length of the source identifier in bytes
+	ExtraHeadersLength     uint16 // Length of the JSON extra headers in bytes
+	DataLength             uint32 // Length of the data payload in bytes
+}
+
+// StartTime returns the time of the first sample.
+func (h RecordHeader3) StartTime() time.Time {
+	return time.Date(
+		int(h.Year),
+		1,
+		1,
+		int(h.Hour),
+		int(h.Minute),
+		int(h.Second),
+		int(h.Nanosecond),
+		time.UTC,
+	).AddDate(0, 0, int(h.DayOfYear)-1)
+}
+
+// SetStartTime sets the header time fields.
+func (h *RecordHeader3) SetStartTime(t time.Time) {
+	t = t.UTC()
+
+	h.Year = uint16(t.Year())
+	h.DayOfYear = uint16(t.YearDay())
+	h.Hour = uint8(t.Hour())
+	h.Minute = uint8(t.Minute())
+	h.Second = uint8(t.Second())
+	h.Nanosecond = uint32(t.Nanosecond())
+}
+
+// SetSampleRate stores a sample rate, rates below one sample per second are stored as a negative period.
+func (h *RecordHeader3) SetSampleRate(rate float64) {
+	switch {
+	case rate > 0 && rate < 1:
+		h.SampleRate = -1.0 / rate
+	default:
+		h.SampleRate = rate
+	}
+}
+
+// SamplesPerSecond returns the decoded header sampling rate in samples per second.
+func (h RecordHeader3) SamplesPerSecond() float64 {
+	switch r := h.SampleRate; {
+	case r < 0:
+		return -1.0 / r
+	default:
+		return r
+	}
+}
+
+// SamplePeriod converts the sample rate into a time interval, or zero.
+func (h RecordHeader3) SamplePeriod() time.Duration {
+	if sps := h.SamplesPerSecond(); sps > 0.0 {
+		return time.Duration(float64(time.Second) / sps)
+	}
+	return 0
+}
+
+// SampleCount returns the number of samples in the record, independent of whether they are decoded or not.
+func (h RecordHeader3) SampleCount() int {
+	return int(h.NumberOfSamples)
+}
+
+// BlockSize returns the total length of the record.
+func (h RecordHeader3) BlockSize() int {
+	return RecordHeader3Size + int(h.SourceIdentifierLength) + int(h.ExtraHeadersLength) + int(h.DataLength)
+}
+
+// IsValid performs a simple consistency check of the RecordHeader3 contents.
+func (h RecordHeader3) IsValid() bool {
+	if h.RecordIndicator != [2]byte{'M', 'S'} || h.FormatVersion != FormatVersion3 {
+		return false
+	}
+	if !(h.DayOfYear >= 1 && h.DayOfYear <= 366) {
+		return false
+	}
+	if !(h.Hour <= 23 && h.Minute <= 59 && h.Second <= 60 && h.Nanosecond < 1000000000) {
+		return false
+	}
+	return true
+}
+
+// DecodeRecordHeader3 returns a RecordHeader3 from a byte slice.
+func DecodeRecordHeader3(data []byte) RecordHeader3 {
+	var h [RecordHeader3Size]byte
+
+	copy(h[:], data)
+
+	return RecordHeader3{
+		RecordIndicator: [2]byte{h[0], h[1]},
+		FormatVersion:   h[2],
+		Flags:           h[3],
+
+		Nanosecond: binary.LittleEndian.Uint32(h[4:8]),
+		Year:       binary.LittleEndian.Uint16(h[8:10]),
+		DayOfYear:  binary.LittleEndian.Uint16(h[10:12]),
+		Hour:       h[12],
+		Minute:     h[13],
+		Second:     h[14],
+
+		Encoding:        h[15],
+		SampleRate:      math.Float64frombits(binary.LittleEndian.Uint64(h[16:24])),
+		NumberOfSamples: binary.LittleEndian.Uint32(h[24:28]),
+		CRC:             binary.LittleEndian.Uint32(h[28:32]),
+
+		PublicationVersion: h[32],
+
+		SourceIdentifierLength: h[33],
+		ExtraHeadersLength:     binary.LittleEndian.Uint16(h[34:36]),
+		DataLength:             binary.LittleEndian.Uint32(h[36:40]),
+	}
+}
+
+// EncodeRecordHeader3 converts a RecordHeader3 into a byte slice.
+func EncodeRecordHeader3(hdr RecordHeader3) []byte {
+	var b [RecordHeader3Size]byte
+
+	copy(b[0:2], hdr.RecordIndicator[:])
+	b[2] = hdr.FormatVersion
+	b[3] = hdr.Flags
+
+	binary.LittleEndian.PutUint32(b[4:8], hdr.Nanosecond)
+	binary.LittleEndian.PutUint16(b[8:10], hdr.Year)
+	binary.LittleEndian.PutUint16(b[10:12], hdr.DayOfYear)
+	b[12] = hdr.Hour
+	b[13] = hdr.Minute
+	b[14] = hdr.Second
+
+	b[15] = hdr.Encoding
+	binary.LittleEndian.PutUint64(b[16:24], math.Float64bits(hdr.SampleRate))
+	binary.LittleEndian.PutUint32(b[24:28], hdr.NumberOfSamples)
+	binary.LittleEndian.PutUint32(b[28:32], hdr.CRC)
+
+	b[32] = hdr.PublicationVersion
+
+	b[33] = hdr.SourceIdentifierLength
+	binary.LittleEndian.PutUint16(b[34:36], hdr.ExtraHeadersLength)
+	binary.LittleEndian.PutUint32(b[36:40], hdr.DataLength)
+
+	h := make([]byte, RecordHeader3Size)
+	copy(h[0:RecordHeader3Size], b[:])
+
+	return h
+}
+
+func (h *RecordHeader3) Unmarshal(data []byte) error {
+	*h = DecodeRecordHeader3(data)
+	return nil
+}
+
+func (h RecordHeader3) Marshal() ([]byte, error) {
+	return EncodeRecordHeader3(h), nil
+}
+
+func (h RecordHeader3) Encode(wr io.Writer) error {
+	if _, err := wr.Write(EncodeRecordHeader3(h)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package ms
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"strings"
 	"time"
@@ -27,6 +28,7 @@ func NewEmptyRecord(reclen, factor, multi int) *Record {
 			WordOrder:    uint8(BigEndian),
 			RecordLength: uint8(reclen),
 		},
+		layout: []uint16{1000, 1001},
 	}
 
 	return &rec
@@ -81,7 +83,11 @@ func (r *Record) SetSampleRateMultiplier(mult int) {
 func (r *Record) PackASCII(start time.Time, raw []string, fn RecordFunc) error {
 
 	samples := append([]string(nil), raw...)
-	size := (r.BlockSize() - int(r.BeginningOfData))
+	length, err := r.dataLength()
+	if err != nil {
+		return err
+	}
+	size := length
 	data := []byte(strings.Join(samples, "\n"))
 	blocks := make([][]byte, 0, (len(samples)+size-1)/size)
 	for size < len(data) {
@@ -94,6 +100,8 @@ func (r *Record) PackASCII(start time.Time, raw []string, fn RecordFunc) error {
 			RecordHeader: r.RecordHeader,
 			B1000:        r.B1000,
 			B1001:        r.B1001,
+			Blockettes:   r.Blockettes,
+			layout:       r.layout,
 			Data:         b,
 		}
 
@@ -109,11 +117,24 @@ func (r *Record) PackASCII(start time.Time, raw []string, fn RecordFunc) error {
 	return nil
 }
 
+// dataLength returns the space available for sample data in each packed Record.
+func (r *Record) dataLength() (int, error) {
+	n := r.BlockSize() - int(r.BeginningOfData)
+	if !(n > 0) {
+		return 0, fmt.Errorf("pack: no space for data in a %d byte record beginning at %d", r.BlockSize(), r.BeginningOfData)
+	}
+	return n, nil
+}
+
 // PackInt32 takes an int32 slice and packs it into miniseed Records which are passed to a callback function.
 func (r *Record) PackInt32(start time.Time, raw []int32, fn RecordFunc) error {
 
 	samples := append([]int32(nil), raw...)
-	size := (r.BlockSize() - int(r.BeginningOfData)) / 4
+	length, err := r.dataLength()
+	if err != nil {
+		return err
+	}
+	size := length / 4
 	blocks := make([][]int32, 0, (len(samples)+size-1)/size)
 	for size < len(samples) {
 		samples, blocks = samples[size:], append(blocks, samples[0:size:size])
@@ -134,10 +155,12 @@ func (r *Record) PackInt32(start time.Time, raw []int32, fn RecordFunc) error {
 			RecordHeader: r.RecordHeader,
 			B1000:        r.B1000,
 			B1001:        r.B1001,
+			Blockettes:   r.Blockettes,
+			layout:       r.layout,
 			Data:         block,
 		}
 
-		offset := start.Add(time.Duration(count) * r.SamplePeriod())
+		offset := start.Add(r.SampleOffset(count))
 		btime := NewBTime(offset)
 
 		rec.RecordHeader.RecordStartTime = btime
@@ -159,7 +182,11 @@ func (r *Record) PackInt32(start time.Time, raw []int32, fn RecordFunc) error {
 func (r *Record) PackFloat32(start time.Time, raw []float32, fn RecordFunc) error {
 
 	samples := append([]float32(nil), raw...)
-	size := (r.BlockSize() - int(r.BeginningOfData)) / 4
+	length, err := r.dataLength()
+	if err != nil {
+		return err
+	}
+	size := length / 4
 	blocks := make([][]float32, 0, (len(samples)+size-1)/size)
 	for size < len(samples) {
 		samples, blocks = samples[size:], append(blocks, samples[0:size:size])
@@ -181,10 +208,12 @@ func (r *Record) PackFloat32(start time.Time, raw []float32, fn RecordFunc) erro
 			RecordHeader: r.RecordHeader,
 			B1000:        r.B1000,
 			B1001:        r.B1001,
+			Blockettes:   r.Blockettes,
+			layout:       r.layout,
 			Data:         block,
 		}
 
-		offset := start.Add(time.Duration(count) * r.SamplePeriod())
+		offset := start.Add(r.SampleOffset(count))
 		btime := NewBTime(offset)
 
 		rec.RecordHeader.RecordStartTime = btime
@@ -206,7 +235,11 @@ func (r *Record) PackFloat32(start time.Time, raw []float32, fn RecordFunc) erro
 func (r *Record) PackFloat64(start time.Time, raw []float64, fn RecordFunc) error {
 
 	samples := append([]float64(nil), raw...)
-	size := (r.BlockSize() - int(r.BeginningOfData)) / 8
+	length, err := r.dataLength()
+	if err != nil {
+		return err
+	}
+	size := length / 8
 	blocks := make([][]float64, 0, (len(samples)+size-1)/size)
 	for size < len(samples) {
 		samples, blocks = samples[size:], append(blocks, samples[0:size:size])
@@ -228,10 +261,12 @@ func (r *Record) PackFloat64(start time.Time, raw []float64, fn RecordFunc) erro
 			RecordHeader: r.RecordHeader,
 			B1000:        r.B1000,
 			B1001:        r.B1001,
+			Blockettes:   r.Blockettes,
+			layout:       r.layout,
 			Data:         block,
 		}
 
-		offset := start.Add(time.Duration(count) * r.SamplePeriod())
+		offset := start.Add(r.SampleOffset(count))
 		btime := NewBTime(offset)
 
 		rec.RecordHeader.RecordStartTime = btime
@@ -250,10 +285,16 @@ func (r *Record) PackFloat64(start time.Time, raw []float64, fn RecordFunc) erro
 }
 
 // PackFloatSteim1 takes an int32 slice and packs it into miniseed Records, using Steim1 compression, which are passed to a callback function.
+// The prev value is used to build the first difference and should be the last sample of any preceding record in the stream,
+// the frames are written using the Record word order.
 func (r *Record) PackSteim1(start time.Time, prev int32, raw []int32, fn RecordFunc) error {
 
 	samples := append([]int32(nil), raw...)
-	frames := (r.BlockSize() - int(r.BeginningOfData)) / 64
+	length, err := r.dataLength()
+	if err != nil {
+		return err
+	}
+	frames := length / 64
 
 	var count int
 	if err := packSteim(1, frames, prev, samples, func(buf []byte, index uint16, frames uint8) error {
@@ -261,16 +302,20 @@ func (r *Record) PackSteim1(start time.Time, prev int32, raw []int32, fn RecordF
 			RecordHeader: r.RecordHeader,
 			B1000:        r.B1000,
 			B1001:        r.B1001,
+			Blockettes:   r.Blockettes,
+			layout:       r.layout,
 			Data:         buf,
 		}
 
-		offset := start.Add(time.Duration(count) * r.SamplePeriod())
+		offset := start.Add(r.SampleOffset(count))
 		btime := NewBTime(offset)
 
 		rec.RecordHeader.RecordStartTime = btime
 		rec.RecordHeader.NumberOfSamples = index
 		rec.B1000.Encoding = uint8(EncodingSTEIM1)
-		rec.B1000.WordOrder = uint8(BigEndian)
+		if rec.IsLittleEndian() {
+			rec.Data = swapSteim(1, buf, false)
+		}
 		rec.B1001.MicroSec = int8(offset.Sub(btime.Time()) / time.Microsecond)
 		rec.B1001.FrameCount = frames
 
@@ -289,10 +334,16 @@ func (r *Record) PackSteim1(start time.Time, prev int32, raw []int32, fn RecordF
 }
 
 // PackFloatSteim2 takes an int32 slice and packs it into miniseed Records, using Steim2 compression, which are passed to a callback function.
-func (r *Record) PackSteim2(start time.Time, prev int32, raw []int32, fn func(*Record) error) error {
+// The prev value is used to build the first difference and should be the last sample of any preceding record in the stream,
+// the frames are written using the Record word order.
+func (r *Record) PackSteim2(start time.Time, prev int32, raw []int32, fn RecordFunc) error {
 
 	samples := append([]int32(nil), raw...)
-	frames := (r.BlockSize() - int(r.BeginningOfData)) / 64
+	length, err := r.dataLength()
+	if err != nil {
+		return err
+	}
+	frames := length / 64
 
 	var count int
 	if err := packSteim(2, frames, prev, samples, func(buf []byte, index uint16, frames uint8) error {
@@ -301,16 +352,20 @@ func (r *Record) PackSteim2(start time.Time, prev int32, raw []int32, fn func(*R
 			RecordHeader: r.RecordHeader,
 			B1000:        r.B1000,
 			B1001:        r.B1001,
+			Blockettes:   r.Blockettes,
+			layout:       r.layout,
 			Data:         buf,
 		}
 
-		offset := start.Add(time.Duration(count) * r.SamplePeriod())
+		offset := start.Add(r.SampleOffset(count))
 		btime := NewBTime(offset)
 
 		rec.RecordHeader.RecordStartTime = btime
 		rec.RecordHeader.NumberOfSamples = index
 		rec.B1000.Encoding = uint8(EncodingSTEIM2)
-		rec.B1000.WordOrder = uint8(BigEndian)
+		if rec.IsLittleEndian() {
+			rec.Data = swapSteim(2, buf, false)
+		}
 		rec.B1001.MicroSec = int8(offset.Sub(btime.Time()) / time.Microsecond)
 		rec.B1001.FrameCount = frames
 
@@ -340,7 +395,9 @@ func (r *Record) Marshal() ([]byte, error) {
 	return blk, nil
 }
 
-// Encode writes a miniseed formatted byte slice into the given Writer.
+// Encode writes a miniseed formatted byte slice into the given Writer. The blockettes are written
+// in the order they were unpacked or added, otherwise the Blockette 1000 is written first, followed
+// by any Blockette 1001 and then the other blockettes in order.
 func (r *Record) Encode(wr io.Writer) error {
 
 	// encode the header into the buffer
@@ -358,40 +415,39 @@ func (r *Record) Encode(wr io.Writer) error {
 		}
 	}
 
-	// where the next blockette will be if present
-	offset += BlocketteHeaderSize + Blockette1000Size
-
-	b1000 := BlocketteHeader{
-		BlocketteType: 1000,
-		NextBlockette: func() uint16 {
-			if r.RecordHeader.NumberOfBlockettesThatFollow > 1 {
-				return uint16(offset)
-			}
-			return 0
-		}(),
-	}
-	if err := b1000.Encode(wr); err != nil {
-		return err
-	}
-	if err := r.B1000.Encode(wr); err != nil {
-		return err
-	}
+	blockettes := r.sequence()
 
-	if r.RecordHeader.NumberOfBlockettesThatFollow > 1 {
-		// where the next blockette will be if present
-		offset += BlocketteHeaderSize + Blockette1001Size
+	for i, b := range blockettes {
+		data, err := b.Marshal()
+		if err != nil {
+			return err
+		}
 
-		b1001 := BlocketteHeader{
-			BlocketteType: 1001,
+		// where the next blockette will be if present
+		offset += BlocketteHeaderSize + len(data)
+
+		hdr := BlocketteHeader{
+			BlocketteType: b.BlocketteType(),
+			NextBlockette: func() uint16 {
+				if i < len(blockettes)-1 {
+					return uint16(offset)
+				}
+				return 0
+			}(),
 		}
-		if err := b1001.Encode(wr); err != nil {
+		if err := hdr.Encode(wr); err != nil {
 			return err
 		}
-		if err := r.B1001.Encode(wr); err != nil {
+		if _, err := wr.Write(data); err != nil {
 			return err
 		}
 	}
 
+	// check the blockettes have not overrun the data
+	if offset > int(r.BeginningOfData) {
+		return fmt.Errorf("encode: blockettes end at %d, beyond the beginning of data at %d", offset, r.BeginningOfData)
+	}
+
 	// add any space between the blockettes and the data
 	if n := int(r.BeginningOfData) - offset; n > 0 {
 		if _, err := wr.Write(make([]byte, n)); err != nil {
@@ -83,24 +83,28 @@ func minPackBits(diff int32) int32 {
 	}
 }
 
-func encodeSteim(version int, nf int, d0 int32, data []int32) ([]byte, int, int) {
+// encodeSteim packs as many samples as will fit into nf frames, the first difference is
+// taken from the given previous sample, which should be the last sample of any preceding record.
+func encodeSteim(version int, nf int, prev int32, data []int32) ([]byte, int, int) {
 	switch version {
 	case 1:
-		return encodeSteim1(nf, d0, data)
+		return encodeSteim1(nf, prev, data)
 	case 2:
-		return encodeSteim2(nf, d0, data)
+		return encodeSteim2(nf, prev, data)
 	default:
 		return nil, 0, 0
 	}
 }
 
-func packSteim(version int, nf int, d0 int32, raw []int32, fn func([]byte, uint16, uint8) error) error {
+// packSteim encodes the samples into a sequence of records, the difference chain is carried
+// across records starting from the given previous sample.
+func packSteim(version int, nf int, prev int32, raw []int32, fn func([]byte, uint16, uint8) error) error {
 
 	// make a copy to avoid hidden caller problems
 	data := append([]int32(nil), raw...)
 
 	for len(data) > 0 {
-		res, ns, fs := encodeSteim(version, nf, d0, data)
+		res, ns, fs := encodeSteim(version, nf, prev, data)
 		if fs < 0 {
 			return fmt.Errorf("unable to represent difference in <= 30 bits")
 		}
@@ -110,13 +114,13 @@ func packSteim(version int, nf int, d0 int32, raw []int32, fn func([]byte, uint1
 		if err := fn(res, uint16(ns), uint8(fs)); err != nil {
 			return err
 		}
-		d0, data = data[ns-1], data[ns:]
+		prev, data = data[ns-1], data[ns:]
 	}
 
 	return nil
 }
 
-func encodeSteim1(nf int, d0 int32, data []int32) ([]byte, int, int) {
+func encodeSteim1(nf int, prev int32, data []int32) ([]byte, int, int) {
 	// running counts
 	var fn, wn, pn int
 
@@ -128,7 +132,7 @@ func encodeSteim1(nf int, d0 int32, data []int32) ([]byte, int, int) {
 	ns, pr := len(data), len(data)
 
 	// calculate initial difference and minbits buffers /
-	diff[0] = d0
+	diff[0] = data[0] - prev
 	minbits[0] = minPackBits(diff[0])
 	for i := 1; i < 4 && i < ns; i++ {
 		diff[i] = data[i] - data[i-1]
@@ -208,14 +212,14 @@ func encodeSteim1(nf int, d0 int32, data []int32) ([]byte, int, int) {
 
 	// convert frames into a byte slice
 	var res []byte
-	for i := 0; i < fn; i++ {
+	for i := 0; i < nf; i++ {
 		res = append(res, fr[i].Encode()...)
 	}
 
 	return res, pn, fn
 }
 
-func encodeSteim2(nf int, d0 int32, data []int32) ([]byte, int, int) {
+func encodeSteim2(nf int, prev int32, data []int32) ([]byte, int, int) {
 	// running counts
 	var fn, wn, pn int
 
@@ -227,7 +231,7 @@ func encodeSteim2(nf int, d0 int32, data []int32) ([]byte, int, int) {
 	ns, pr := len(data), len(data)
 
 	// calculate initial difference and minbits buffers /
-	diff[0] = d0
+	diff[0] = data[0] - prev
 	minbits[0] = minPackBits(diff[0])
 	for i := 1; i < 7 && i < ns; i++ {
 		diff[i] = data[i] - data[i-1]
@@ -70,3 +70,83 @@ func TestRecord_Repack(t *testing.T) {
 		})
 	}
 }
+
+func TestRecord_PackSteim(t *testing.T) {
+
+	files := []string{
+		"NZ.AUCT.40.BTT.mseed",
+		"NZ.CHIT.40.BTT.mseed",
+		"steim1.mseed",
+	}
+
+	for _, k := range files {
+		raw, err := os.ReadFile("testdata/" + k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rec Record
+		if err := rec.Unpack(raw); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := rec.Int32s()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// make the samples span multiple records
+		var samples []int32
+		for i := 0; i < 4; i++ {
+			samples = append(samples, data...)
+		}
+
+		packers := map[string]func(time.Time, int32, []int32, RecordFunc) error{
+			"steim1": rec.PackSteim1,
+			"steim2": rec.PackSteim2,
+		}
+
+		for p, pack := range packers {
+			t.Run(p+": "+k, func(t *testing.T) {
+				var packed []int32
+				if err := pack(rec.StartTime(), 0, samples, func(msr *Record) error {
+					if n := msr.BlockSize() - int(msr.BeginningOfData); len(msr.Data) != n {
+						t.Errorf("invalid data length, expected %d got %d", n, len(msr.Data))
+					}
+					values, err := msr.Int32s()
+					if err != nil {
+						return err
+					}
+					packed = append(packed, values...)
+					return nil
+				}); err != nil {
+					t.Fatal(err)
+				}
+
+				if len(packed) != len(samples) {
+					t.Fatalf("invalid unpacked sample count, expected %d got %d", len(samples), len(packed))
+				}
+				for i := 0; i < len(samples); i++ {
+					if packed[i] != samples[i] {
+						t.Fatalf("invalid unpacked sample %d, expected %d got %d", i, samples[i], packed[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRecord_SteimPrevious(t *testing.T) {
+
+	samples := []int32{12, 13, 15, 18, 22, 27}
+
+	res, ns, fs := encodeSteim(1, 1, 10, samples)
+	if ns != len(samples) || fs != 1 {
+		t.Fatalf("invalid packing, expected %d samples in %d frame, got %d in %d", len(samples), 1, ns, fs)
+	}
+
+	// the first data word holds four byte differences, starting from the previous sample
+	diffs := applyDifferencesFromWord(res[12:16], 4, 8, []int32{0})
+	if d := diffs[1]; d != 2 {
+		t.Errorf("invalid first difference, expected %d got %d", 2, d)
+	}
+}
@@ -12,8 +12,54 @@ type ProcessFn func(src string, start time.Time, delta time.Duration, samples ..
 
 // Process reads miniseed blocks of an expected blksize, these are decoded and passed
 // to the given function. If the function returns an error it is immediately returned
-// by the parent Process function.
+// by the parent Process function. A blksize of zero will detect the length of each block.
 func Process(rd io.Reader, blksize int, fn ProcessFn) error {
+	return process(rd, blksize, func(msr *Record) error {
+
+		// decode the data samples into floats
+		samples, err := msr.Float64s()
+		if err != nil {
+			return err
+		}
+
+		// pass the results to the process function
+		return fn(msr.SrcName(false), msr.StartTime(), msr.SamplePeriod(), samples...)
+	})
+}
+
+// ProcessSelection is the same as Process except only blocks that match the Selection are passed
+// to the given function, the samples are trimmed to fit inside the selection time window.
+func ProcessSelection(rd io.Reader, blksize int, sel Selection, fn ProcessFn) error {
+	return process(rd, blksize, func(msr *Record) error {
+		if !sel.Match(*msr) {
+			return nil
+		}
+
+		first, last := sel.Window(*msr)
+		if !(last > first) {
+			return nil
+		}
+
+		// decode the data samples into floats
+		samples, err := msr.Float64s()
+		if err != nil {
+			return err
+		}
+
+		// adjust the start time to the first selected sample
+		start := msr.StartTime().Add(time.Duration(first) * msr.SamplePeriod())
+
+		// pass the results to the process function
+		return fn(msr.SrcName(false), start, msr.SamplePeriod(), samples[first:last]...)
+	})
+}
+
+// process reads miniseed blocks of an expected blksize and passes each decoded record to the given function.
+func process(rd io.Reader, blksize int, fn RecordFunc) error {
+
+	if !(blksize > 0) {
+		return processRecords(rd, fn)
+	}
 
 	buf := make([]byte, blksize)
 	for {
@@ -30,17 +76,23 @@ func Process(rd io.Reader, blksize int, fn ProcessFn) error {
 			return err
 		}
 
-		// decode the data samples into floats
-		samples, err := msr.Float64s()
-		if err != nil {
+		if err := fn(msr); err != nil {
 			return err
 		}
+	}
+}
 
-		// pass the results to the process function
-		if err := fn(msr.SrcName(false), msr.StartTime(), msr.SamplePeriod(), samples...); err != nil {
+// processRecords uses a Reader to handle blocks of differing lengths.
+func processRecords(rd io.Reader, fn RecordFunc) error {
+
+	reader := NewReader(rd)
+	for reader.Next() {
+		if err := fn(reader.Record()); err != nil {
 			return err
 		}
 	}
+
+	return reader.Err()
 }
 
 // ProcessFile reads a file for miniseed blocks of an expected blksize,
@@ -0,0 +1,162 @@
+package ms
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// MinRecordLength is the smallest supported record length as a power of two.
+	MinRecordLength = 7
+	// MaxRecordLength is the largest supported record length as a power of two.
+	MaxRecordLength = 16
+)
+
+// Reader decodes a stream of miniseed records which may have differing record lengths,
+// the length of each record is taken from its Blockette 1000 if present, otherwise it is
+// found by probing for the following record header.
+type Reader struct {
+	rd     *bufio.Reader
+	offset int64
+	record *Record
+	err    error
+}
+
+// NewReader returns a Reader that decodes records from the given io.Reader.
+func NewReader(rd io.Reader) *Reader {
+	return &Reader{
+		rd: bufio.NewReaderSize(rd, (1<<MaxRecordLength)+RecordHeaderSize),
+	}
+}
+
+// isHeader performs a more thorough check of whether a byte slice holds a record header.
+func isHeader(data []byte) bool {
+	if len(data) < RecordHeaderSize {
+		return false
+	}
+
+	hdr := DecodeRecordHeader(data)
+	if !hdr.IsValid() {
+		return false
+	}
+	if t := hdr.RecordStartTime; t.Year < 1900 || t.Year > 2100 || t.Doy < 1 || t.Doy > 366 {
+		return false
+	}
+	for _, b := range hdr.StationIdentifier {
+		if !(b == ' ' || (b >= '0' && b <= '9') || (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// blockette1000 searches the blockette chain for a Blockette 1000 record length.
+func (r *Reader) blockette1000(hdr RecordHeader) (int, bool) {
+	pointer := int(hdr.FirstBlockette)
+	for i := 0; i < int(hdr.NumberOfBlockettesThatFollow) && pointer >= RecordHeaderSize; i++ {
+		data, err := r.rd.Peek(pointer + BlocketteHeaderSize + Blockette1000Size)
+		if err != nil {
+			return 0, false
+		}
+		bhead := DecodeBlocketteHeader(data[pointer:])
+		if bhead.BlocketteType == 1000 {
+			b1000 := DecodeBlockette1000(data[pointer+BlocketteHeaderSize:])
+			if n := int(b1000.RecordLength); n >= MinRecordLength && n <= MaxRecordLength {
+				return 1 << n, true
+			}
+			return 0, false
+		}
+		pointer = int(bhead.NextBlockette)
+	}
+	return 0, false
+}
+
+// probe looks for the next record header, or the end of the stream, to find the record length.
+func (r *Reader) probe() (int, bool) {
+	for n := MinRecordLength; n <= MaxRecordLength; n++ {
+		size := 1 << n
+		data, err := r.rd.Peek(size + RecordHeaderSize)
+		switch {
+		case len(data) == size:
+			return size, true
+		case err != nil && len(data) < size:
+			return 0, false
+		case isHeader(data[size:]):
+			return size, true
+		}
+	}
+	return 0, false
+}
+
+// Next reads and decodes the next record, which is then available via Record.
+// It returns false at the end of the stream or if an error occurred, in which
+// case Err will report the problem.
+func (r *Reader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	data, err := r.rd.Peek(RecordHeaderSize)
+	switch {
+	case len(data) == 0 && err == io.EOF:
+		return false
+	case len(data) < RecordHeaderSize && (err == io.EOF || err == nil):
+		r.err = fmt.Errorf("offset %d: %w: found %d bytes", r.offset, io.ErrUnexpectedEOF, len(data))
+		return false
+	case err != nil:
+		r.err = fmt.Errorf("offset %d: %w", r.offset, err)
+		return false
+	}
+
+	if !isHeader(data) {
+		r.err = fmt.Errorf("offset %d: invalid record header", r.offset)
+		return false
+	}
+
+	size, ok := r.blockette1000(DecodeRecordHeader(data))
+	if !ok {
+		if size, ok = r.probe(); !ok {
+			r.err = fmt.Errorf("offset %d: unable to determine record length", r.offset)
+			return false
+		}
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r.rd, buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		r.err = fmt.Errorf("offset %d: %w", r.offset, err)
+		return false
+	}
+
+	record, err := NewRecord(buf)
+	if err != nil {
+		r.err = fmt.Errorf("offset %d: %w", r.offset, err)
+		return false
+	}
+
+	r.record = record
+	r.offset += int64(size)
+
+	return true
+}
+
+// Record returns the most recent Record decoded by Next.
+func (r *Reader) Record() *Record {
+	return r.record
+}
+
+// Offset returns the number of bytes consumed so far.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Err returns the first error encountered by the Reader, or nil if the stream
+// was read cleanly through to the end.
+func (r *Reader) Err() error {
+	return r.err
+}
@@ -0,0 +1,108 @@
+package ms
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestReader_Mixed(t *testing.T) {
+
+	files := []string{
+		"basic.mseed",
+		"4096_float.mseed",
+		"NZ.AUCT.40.BTT.mseed",
+		"4096_float.mseed",
+		"steim1.mseed",
+	}
+
+	var data []byte
+	var expected []string
+	for _, k := range files {
+		raw, err := os.ReadFile("testdata/" + k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msr, err := NewRecord(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, raw...)
+		expected = append(expected, msr.String())
+	}
+
+	var res []string
+	rd := NewReader(bytes.NewReader(data))
+	for rd.Next() {
+		res = append(res, rd.Record().String())
+	}
+	if err := rd.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != len(expected) {
+		t.Fatalf("invalid number of records, expected %d got %d", len(expected), len(res))
+	}
+	for i := range res {
+		if res[i] != expected[i] {
+			t.Errorf("invalid record %d, expected \"%s\", got \"%s\"", i, expected[i], res[i])
+		}
+	}
+	if n := rd.Offset(); n != int64(len(data)) {
+		t.Errorf("invalid offset, expected %d got %d", len(data), n)
+	}
+}
+
+func TestReader_Probe(t *testing.T) {
+
+	raw, err := os.ReadFile("testdata/basic.mseed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// remove the blockettes so that the record length must be found by probing
+	stripped := append([]byte(nil), raw...)
+	stripped[39] = 0
+
+	var data []byte
+	for i := 0; i < 3; i++ {
+		data = append(data, stripped...)
+	}
+
+	var count int
+	rd := NewReader(bytes.NewReader(data))
+	for rd.Next() {
+		if n := len(rd.Record().Data); n != len(raw)-int(rd.Record().BeginningOfData) {
+			t.Errorf("invalid record data length, got %d", n)
+		}
+		count++
+	}
+	if err := rd.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("invalid number of records, expected %d got %d", 3, count)
+	}
+}
+
+func TestReader_Short(t *testing.T) {
+
+	raw, err := os.ReadFile("testdata/basic.mseed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := append(append([]byte(nil), raw...), raw[:100]...)
+
+	var count int
+	rd := NewReader(bytes.NewReader(data))
+	for rd.Next() {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("invalid number of records, expected %d got %d", 1, count)
+	}
+	if rd.Err() == nil {
+		t.Errorf("expected an error for a truncated record")
+	}
+}
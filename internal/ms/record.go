@@ -2,6 +2,7 @@ package ms
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 )
@@ -40,7 +41,12 @@ type Record struct {
 	B1000 Blockette1000 //If Present
 	B1001 Blockette1001 //If Present
 
+	Blockettes []Blockette // Any other blockettes, in record order
+
 	Data []byte
+
+	// layout holds the type of each blockette in record order, including the Blockette 1000 and 1001.
+	layout []uint16
 }
 
 // NewMSRecord decodes and unpacks the record samples from a byte slice and returns a Record pointer,
@@ -81,17 +87,172 @@ func (m Record) StartTime() time.Time {
 	return t
 }
 
-// EndTime returns the calculated time of the last sample.
+// EndTime returns the calculated time of the last sample, this uses the actual sample rate if known.
 func (m Record) EndTime() time.Time {
 	var d time.Duration
 
-	if sc, sr := m.SampleCount(), m.SampleRate(); sc > 0 && sr > 0 {
-		d = time.Duration(m.SampleCount()-1) * time.Duration(float64(time.Second)/m.SampleRate()+0.5)
+	if sc := m.SampleCount(); sc > 0 {
+		d = m.SampleOffset(sc - 1)
 	}
 
 	return m.StartTime().Add(d)
 }
 
+// ActualSampleRate returns the sample rate given in any Blockette 100, otherwise the nominal header sample rate.
+func (m Record) ActualSampleRate() float64 {
+	if blk, ok := m.Blockette(100); ok {
+		if rate := float64(blk.(Blockette100).SampleRate); rate > 0 {
+			return rate
+		}
+	}
+	return m.SampleRate()
+}
+
+// SetActualSampleRate stores the measured sample rate in a Blockette 100, one is added if not already present.
+func (m *Record) SetActualSampleRate(rate float64) {
+	for i, b := range m.Blockettes {
+		if blk, ok := b.(Blockette100); ok {
+			blk.SampleRate = float32(rate)
+			m.Blockettes[i] = blk
+			return
+		}
+	}
+	m.AddBlockette(Blockette100{SampleRate: float32(rate)})
+}
+
+// SampleOffset returns the time of the given sample relative to the first sample, this uses the actual sample
+// rate if known and is calculated in floating point to avoid accumulating any rounding of the sample period.
+func (m Record) SampleOffset(n int) time.Duration {
+	if sr := m.ActualSampleRate(); sr > 0 {
+		return time.Duration(math.Round(float64(n) / sr * float64(time.Second)))
+	}
+	return 0
+}
+
+// SamplePeriod converts the actual sample rate into a time interval, or zero.
+func (m Record) SamplePeriod() time.Duration {
+	if blk, ok := m.Blockette(100); ok {
+		if rate := float64(blk.(Blockette100).SampleRate); rate > 0 {
+			return time.Duration(float64(time.Second)/rate + 0.5)
+		}
+	}
+	return m.RecordHeader.SamplePeriod()
+}
+
+// Blockette returns the first of any other blockettes with the given type.
+func (m Record) Blockette(kind uint16) (Blockette, bool) {
+	for _, b := range m.Blockettes {
+		if b.BlocketteType() == kind {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// HasBlockette1001 returns whether the record holds a Blockette 1001, for records that were not
+// unpacked or created as empty records this is inferred from the header blockette count.
+func (m Record) HasBlockette1001() bool {
+	if m.layout != nil {
+		for _, t := range m.layout {
+			if t == 1001 {
+				return true
+			}
+		}
+		return false
+	}
+	return int(m.NumberOfBlockettesThatFollow) > 1+len(m.Blockettes)
+}
+
+// sequence returns the blockettes in the order they should be encoded. This follows the record
+// layout if it still matches the blockettes, otherwise the Blockette 1000 is first, followed by any
+// Blockette 1001 and then the other blockettes.
+func (m Record) sequence() []Blockette {
+	if seq, ok := m.ordered(); ok {
+		return seq
+	}
+
+	seq := []Blockette{m.B1000}
+	if m.HasBlockette1001() {
+		seq = append(seq, m.B1001)
+	}
+	return append(seq, m.Blockettes...)
+}
+
+// ordered returns the blockettes in layout order, and whether the layout matches the blockettes.
+func (m Record) ordered() ([]Blockette, bool) {
+	if m.layout == nil {
+		return nil, false
+	}
+
+	var seq []Blockette
+
+	var n int
+	var found bool
+	for _, t := range m.layout {
+		switch {
+		case t == 1000 && !found:
+			seq, found = append(seq, m.B1000), true
+		case t == 1001:
+			seq = append(seq, m.B1001)
+		case n < len(m.Blockettes) && m.Blockettes[n].BlocketteType() == t:
+			seq, n = append(seq, m.Blockettes[n]), n+1
+		default:
+			return nil, false
+		}
+	}
+
+	return seq, found && n == len(m.Blockettes)
+}
+
+// AddBlockette appends a blockette to the Record, the header blockette count is updated and the
+// beginning of data is moved if needed, this is kept on a 64 byte boundary to suit steim frames.
+func (m *Record) AddBlockette(blk Blockette) {
+	if m.layout == nil {
+		m.layout = m.blocketteTypes()
+	}
+
+	// the layout may be shared with a template record.
+	m.layout = append(m.layout[:len(m.layout):len(m.layout)], blk.BlocketteType())
+
+	m.Blockettes = append(m.Blockettes, blk)
+	m.NumberOfBlockettesThatFollow++
+
+	if n := m.blocketteEnd(); n > int(m.BeginningOfData) {
+		m.BeginningOfData = uint16((n + 63) / 64 * 64)
+	}
+}
+
+// Hash can be used for deduping records, it extends the header hash with the blockette 1001
+// microsecond start time offset.
+func (m Record) Hash() string {
+	return m.RecordHeader.hash(m.B1001.MicroSec)
+}
+
+// blocketteTypes returns the blockette types in the order they would be encoded.
+func (m Record) blocketteTypes() []uint16 {
+	var types []uint16
+	for _, b := range m.sequence() {
+		types = append(types, b.BlocketteType())
+	}
+	return types
+}
+
+// blocketteEnd returns the offset of the end of the encoded blockettes.
+func (m Record) blocketteEnd() int {
+	offset := int(m.FirstBlockette) + BlocketteHeaderSize + Blockette1000Size
+	if m.HasBlockette1001() {
+		offset += BlocketteHeaderSize + Blockette1001Size
+	}
+	for _, b := range m.Blockettes {
+		data, err := b.Marshal()
+		if err != nil {
+			continue
+		}
+		offset += BlocketteHeaderSize + len(data)
+	}
+	return offset
+}
+
 // PacketSize returns the length of the packet
 func (m Record) BlockSize() int {
 	if n := int(m.B1000.RecordLength); n > 0 {
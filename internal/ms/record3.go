@@ -0,0 +1,487 @@
+package ms
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// SourceIDPrefix is the namespace used for FDSN source identifiers.
+const SourceIDPrefix = "FDSN:"
+
+// castagnoli is used to calculate the miniseed 3 CRC-32C checksums.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Record3 is a miniseed 3 record, as described by the FDSN 2023 specification.
+type Record3 struct {
+	RecordHeader3
+
+	SourceIdentifier string // e.g. FDSN:NZ_WEL__H_H_Z
+	ExtraHeaders     []byte // JSON encoded, may be empty
+
+	Data []byte
+}
+
+// ExtraHeaders3 holds the reserved FDSN extra header values that are used when converting records.
+type ExtraHeaders3 struct {
+	FDSN struct {
+		Time struct {
+			Quality *int `json:"Quality,omitempty"`
+		} `json:"Time"`
+	} `json:"FDSN"`
+}
+
+// NewRecord3 decodes a miniseed 3 record from a byte slice and returns a Record3 pointer,
+// or an empty pointer and an error if it could not be decoded.
+func NewRecord3(buf []byte) (*Record3, error) {
+
+	var r Record3
+	if err := r.Unpack(buf); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// String implements the Stringer interface and provides a short summary of the miniseed 3 record header.
+func (m Record3) String() string {
+	var parts []string
+
+	parts = append(parts, m.SourceIdentifier)
+	parts = append(parts, fmt.Sprintf("%d", m.PublicationVersion))
+	parts = append(parts, fmt.Sprintf("%d", m.BlockSize()))
+	parts = append(parts, fmt.Sprintf("%d samples", m.NumberOfSamples))
+	parts = append(parts, fmt.Sprintf("%g Hz", m.SamplesPerSecond()))
+	parts = append(parts, m.StartTime().Format("2006,002,15:04:05.000000000"))
+
+	return strings.Join(parts, ", ")
+}
+
+// EndTime returns the calculated time of the last sample.
+func (m Record3) EndTime() time.Time {
+	var d time.Duration
+
+	if sc, sr := m.SampleCount(), m.SamplesPerSecond(); sc > 0 && sr > 0 {
+		d = time.Duration(sc-1) * time.Duration(float64(time.Second)/sr+0.5)
+	}
+
+	return m.StartTime().Add(d)
+}
+
+// BlockSize returns the total encoded length of the record.
+func (m Record3) BlockSize() int {
+	return RecordHeader3Size + len(m.SourceIdentifier) + len(m.ExtraHeaders) + len(m.Data)
+}
+
+// SrcName returns the stream name built from the source identifier, or the identifier if it is not an FDSN one.
+func (m Record3) SrcName() string {
+	network, station, location, channel, err := ParseSourceID(m.SourceIdentifier)
+	if err != nil {
+		return m.SourceIdentifier
+	}
+	return strings.Join([]string{network, station, location, channel}, "_")
+}
+
+// TimingQuality returns the FDSN timing quality extra header, if present.
+func (m Record3) TimingQuality() (int, bool) {
+	if len(m.ExtraHeaders) == 0 {
+		return 0, false
+	}
+
+	var extra ExtraHeaders3
+	if err := json.Unmarshal(m.ExtraHeaders, &extra); err != nil || extra.FDSN.Time.Quality == nil {
+		return 0, false
+	}
+
+	return *extra.FDSN.Time.Quality, true
+}
+
+// Checksum returns the CRC-32C of an encoded record, calculated with the stored checksum set to zero.
+func Checksum(data []byte) uint32 {
+	if len(data) < RecordHeader3Size {
+		return crc32.Checksum(data, castagnoli)
+	}
+
+	crc := crc32.Update(0, castagnoli, data[:28])
+	crc = crc32.Update(crc, castagnoli, []byte{0, 0, 0, 0})
+	crc = crc32.Update(crc, castagnoli, data[32:])
+
+	return crc
+}
+
+// Unpack decodes a miniseed 3 record from a byte slice, the record checksum is verified.
+func (m *Record3) Unpack(buf []byte) error {
+
+	if len(buf) < RecordHeader3Size {
+		return fmt.Errorf("unpack: given %v bytes; not enough to parse header", len(buf))
+	}
+
+	m.RecordHeader3 = DecodeRecordHeader3(buf[0:RecordHeader3Size])
+	if !m.RecordHeader3.IsValid() {
+		return fmt.Errorf("unpack: input is not a valid MSEED3 record: incorrect header")
+	}
+
+	size := m.RecordHeader3.BlockSize()
+	if len(buf) < size {
+		return fmt.Errorf("unpack: given %v bytes; not enough to parse record of %v bytes", len(buf), size)
+	}
+
+	if crc := Checksum(buf[:size]); crc != m.CRC {
+		return fmt.Errorf("unpack: checksum mismatch, expected %#08x but calculated %#08x", m.CRC, crc)
+	}
+
+	offset := RecordHeader3Size
+
+	m.SourceIdentifier = string(buf[offset : offset+int(m.SourceIdentifierLength)])
+	offset += int(m.SourceIdentifierLength)
+
+	m.ExtraHeaders = nil
+	if n := int(m.ExtraHeadersLength); n > 0 {
+		m.ExtraHeaders = make([]byte, n)
+		copy(m.ExtraHeaders, buf[offset:offset+n])
+	}
+	offset += int(m.ExtraHeadersLength)
+
+	m.Data = make([]byte, int(m.DataLength))
+	copy(m.Data, buf[offset:size])
+
+	return nil
+}
+
+// Marshal converts a Record3 into a byte slice, the variable lengths and the checksum are calculated as part of the encoding.
+func (m Record3) Marshal() ([]byte, error) {
+
+	if len(m.SourceIdentifier) > math.MaxUint8 {
+		return nil, fmt.Errorf("marshal: source identifier too long: %d bytes", len(m.SourceIdentifier))
+	}
+	if len(m.ExtraHeaders) > math.MaxUint16 {
+		return nil, fmt.Errorf("marshal: extra headers too long: %d bytes", len(m.ExtraHeaders))
+	}
+
+	hdr := m.RecordHeader3
+
+	hdr.RecordIndicator = [2]byte{'M', 'S'}
+	hdr.FormatVersion = FormatVersion3
+	hdr.SourceIdentifierLength = uint8(len(m.SourceIdentifier))
+	hdr.ExtraHeadersLength = uint16(len(m.ExtraHeaders))
+	hdr.DataLength = uint32(len(m.Data))
+	hdr.CRC = 0
+
+	data := make([]byte, 0, hdr.BlockSize())
+	data = append(data, EncodeRecordHeader3(hdr)...)
+	data = append(data, m.SourceIdentifier...)
+	data = append(data, m.ExtraHeaders...)
+	data = append(data, m.Data...)
+
+	binary.LittleEndian.PutUint32(data[28:32], Checksum(data))
+
+	return data, nil
+}
+
+// Encode writes a miniseed 3 formatted byte slice into the given Writer.
+func (m Record3) Encode(wr io.Writer) error {
+	data, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+	if _, err := wr.Write(data); err != nil {
+		return err
+	}
+	return nil
+}
+
+// record returns a miniseed 2 Record suitable for decoding the data payload.
+func (m Record3) record() (*Record, error) {
+	if m.NumberOfSamples > math.MaxUint16 {
+		return nil, fmt.Errorf("unable to decode %d samples", m.NumberOfSamples)
+	}
+
+	var r Record
+
+	r.NumberOfSamples = uint16(m.NumberOfSamples)
+	r.B1000.Encoding = m.Encoding
+	r.B1000.WordOrder = uint8(LittleEndian)
+	r.Data = m.Data
+
+	switch Encoding(m.Encoding) {
+	case EncodingSTEIM1, EncodingSTEIM2:
+		r.B1000.WordOrder = uint8(BigEndian)
+		if n := len(m.Data) / 64; n > math.MaxUint8 {
+			return nil, fmt.Errorf("unable to decode %d steim frames", n)
+		}
+	}
+
+	return &r, nil
+}
+
+// Int32s returns the record as a slice of int32 values for numerically encoded records.
+func (m Record3) Int32s() ([]int32, error) {
+	r, err := m.record()
+	if err != nil {
+		return nil, err
+	}
+	return r.Int32s()
+}
+
+// Float64s returns the record as a slice of float64 values for numerically encoded records.
+func (m Record3) Float64s() ([]float64, error) {
+	r, err := m.record()
+	if err != nil {
+		return nil, err
+	}
+	return r.Float64s()
+}
+
+// SourceID builds an FDSN source identifier from SEED stream codes, a three character channel
+// code is split into the band, source and subsource codes.
+func SourceID(network, station, location, channel string) string {
+	parts := []string{network, station, location}
+
+	switch len(channel) {
+	case 3:
+		parts = append(parts, channel[0:1], channel[1:2], channel[2:3])
+	default:
+		parts = append(parts, channel, "", "")
+	}
+
+	return SourceIDPrefix + strings.Join(parts, "_")
+}
+
+// ParseSourceID splits an FDSN source identifier into SEED stream codes, single character band,
+// source and subsource codes are joined into a traditional channel code.
+func ParseSourceID(sid string) (string, string, string, string, error) {
+	if !strings.HasPrefix(sid, SourceIDPrefix) {
+		return "", "", "", "", fmt.Errorf("invalid source identifier %q: missing %q prefix", sid, SourceIDPrefix)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(sid, SourceIDPrefix), "_")
+	if len(parts) != 6 {
+		return "", "", "", "", fmt.Errorf("invalid source identifier %q: expected 6 codes, found %d", sid, len(parts))
+	}
+
+	var channel string
+	switch {
+	case parts[4] == "" && parts[5] == "":
+		channel = parts[3]
+	case len(parts[3]) > 1 || len(parts[4]) > 1 || len(parts[5]) > 1:
+		channel = strings.Join(parts[3:], "_")
+	default:
+		channel = strings.Join(parts[3:], "")
+	}
+
+	return parts[0], parts[1], parts[2], channel, nil
+}
+
+// publicationVersion maps a miniseed 2 quality indicator onto a miniseed 3 publication version.
+func publicationVersion(quality byte) uint8 {
+	switch quality {
+	case 'R':
+		return 1
+	case 'D':
+		return 2
+	case 'Q':
+		return 3
+	case 'M':
+		return 4
+	default:
+		return 0
+	}
+}
+
+// qualityIndicator maps a miniseed 3 publication version onto a miniseed 2 quality indicator.
+func qualityIndicator(version uint8) byte {
+	switch version {
+	case 1:
+		return 'R'
+	case 3:
+		return 'Q'
+	case 4:
+		return 'M'
+	default:
+		return 'D'
+	}
+}
+
+// sampleRateFactors converts a sampling rate into the two seed factors, rates that are not
+// whole numbers of samples or seconds are approximated using a division factor.
+func sampleRateFactors(rate float64) (int16, int16, error) {
+	switch {
+	case !(rate > 0):
+		return 0, 0, nil
+	case rate >= 1 && rate <= math.MaxInt16 && rate == math.Trunc(rate):
+		return int16(rate), 1, nil
+	case rate < 1 && 1/rate <= math.MaxInt16 && 1/rate == math.Trunc(1/rate):
+		return -int16(1 / rate), 1, nil
+	}
+
+	for m := 10000; m > 0; m /= 10 {
+		if f := math.Round(rate * float64(m)); f >= 1 && f <= math.MaxInt16 {
+			return int16(f), -int16(m), nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("unable to represent sample rate %g", rate)
+}
+
+// Record3 converts a miniseed 2 Record into miniseed 3. Any header time correction is applied to the
+// start time, the Blockette 100 sample rate is used if present, and the Blockette 1001 timing quality
+// is stored as an extra header. Numeric samples are stored little endian, and steim frames big endian.
+func (m Record) Record3() (*Record3, error) {
+
+	var r Record3
+
+	r.SetStartTime(m.StartTime())
+	r.Encoding = m.B1000.Encoding
+	r.NumberOfSamples = uint32(m.NumberOfSamples)
+	r.PublicationVersion = publicationVersion(m.DataQualityIndicator)
+
+	r.SetSampleRate(m.ActualSampleRate())
+
+	if isBitSet(m.ActivityFlags, 0) {
+		r.Flags = setBit(r.Flags, 0)
+	}
+	if isBitSet(m.DataQualityFlags, 7) {
+		r.Flags = setBit(r.Flags, 1)
+	}
+	if isBitSet(m.IOAndClockFlags, 5) {
+		r.Flags = setBit(r.Flags, 2)
+	}
+
+	r.SourceIdentifier = SourceID(m.Network(), m.Station(), m.Location(), m.Channel())
+
+	if m.HasBlockette1001() {
+		var extra ExtraHeaders3
+		quality := int(m.B1001.TimingQuality)
+		extra.FDSN.Time.Quality = &quality
+
+		data, err := json.Marshal(extra)
+		if err != nil {
+			return nil, err
+		}
+		r.ExtraHeaders = data
+	}
+
+	n := int(m.NumberOfSamples)
+
+	switch enc := Encoding(m.B1000.Encoding); enc {
+	case EncodingASCII:
+		if n > len(m.Data) {
+			return nil, fmt.Errorf("invalid data length: %d", len(m.Data))
+		}
+		r.Data = append([]byte(nil), m.Data[:n]...)
+	case EncodingInt32, EncodingIEEEFloat, EncodingIEEEDouble:
+		size := 4
+		if enc == EncodingIEEEDouble {
+			size = 8
+		}
+		if n*size > len(m.Data) {
+			return nil, fmt.Errorf("invalid data length: %d", len(m.Data))
+		}
+		r.Data = append([]byte(nil), m.Data[:n*size]...)
+		if m.ByteOrder() == BigEndian {
+			for i := 0; i < len(r.Data); i += size {
+				for j, k := i, i+size-1; j < k; j, k = j+1, k-1 {
+					r.Data[j], r.Data[k] = r.Data[k], r.Data[j]
+				}
+			}
+		}
+	case EncodingSTEIM1, EncodingSTEIM2:
+		frames := len(m.Data) / 64
+		if f := int(m.B1001.FrameCount); f > 0 && f < frames {
+			frames = f
+		}
+		r.Data = append([]byte(nil), m.Data[:frames*64]...)
+		if m.ByteOrder() == LittleEndian {
+			version := 1
+			if enc == EncodingSTEIM2 {
+				version = 2
+			}
+			r.Data = swapSteim(version, r.Data, true)
+		}
+	default:
+		return nil, fmt.Errorf("unable to convert encoding %v", enc)
+	}
+
+	return &r, nil
+}
+
+// Record converts a miniseed 3 record into a miniseed 2 Record, the smallest record length able to hold
+// the data payload is used. The start time is truncated to microseconds and stored using a Blockette 1001.
+func (m Record3) Record() (*Record, error) {
+
+	network, station, location, channel, err := ParseSourceID(m.SourceIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	if len(network) > 2 || len(station) > 5 || len(location) > 2 || len(channel) > 3 {
+		return nil, fmt.Errorf("unable to represent source identifier %q", m.SourceIdentifier)
+	}
+
+	if m.NumberOfSamples > math.MaxUint16 {
+		return nil, fmt.Errorf("unable to represent %d samples", m.NumberOfSamples)
+	}
+
+	factor, multiplier, err := sampleRateFactors(m.SamplesPerSecond())
+	if err != nil {
+		return nil, err
+	}
+
+	reclen := MinRecordLength
+	for reclen <= MaxRecordLength && 64+len(m.Data) > 1<<reclen {
+		reclen++
+	}
+	if reclen > MaxRecordLength {
+		return nil, fmt.Errorf("unable to represent %d bytes of data", len(m.Data))
+	}
+
+	r := NewEmptyRecord(reclen, int(factor), int(multiplier))
+
+	r.SetNetwork(network)
+	r.SetStation(station)
+	r.SetLocation(location)
+	r.SetChannel(channel)
+
+	r.DataQualityIndicator = qualityIndicator(m.PublicationVersion)
+	r.NumberOfSamples = uint16(m.NumberOfSamples)
+
+	start := m.StartTime()
+	r.RecordStartTime = NewBTime(start)
+	r.B1001.MicroSec = int8(start.Sub(r.RecordStartTime.Time()) / time.Microsecond)
+
+	if isBitSet(m.Flags, 0) {
+		r.ActivityFlags = setBit(r.ActivityFlags, 0)
+	}
+	if isBitSet(m.Flags, 1) {
+		r.DataQualityFlags = setBit(r.DataQualityFlags, 7)
+	}
+	if isBitSet(m.Flags, 2) {
+		r.IOAndClockFlags = setBit(r.IOAndClockFlags, 5)
+	}
+
+	if quality, ok := m.TimingQuality(); ok {
+		r.B1001.TimingQuality = uint8(quality)
+	}
+
+	r.B1000.Encoding = m.Encoding
+	switch Encoding(m.Encoding) {
+	case EncodingSTEIM1, EncodingSTEIM2:
+		frames := len(m.Data) / 64
+		if frames > math.MaxUint8 {
+			return nil, fmt.Errorf("unable to represent %d steim frames", frames)
+		}
+		r.B1000.WordOrder = uint8(BigEndian)
+		r.B1001.FrameCount = uint8(frames)
+	default:
+		r.B1000.WordOrder = uint8(LittleEndian)
+	}
+
+	r.Data = make([]byte, r.BlockSize()-int(r.BeginningOfData))
+	copy(r.Data, m.Data)
+
+	return r, nil
+}
@@ -0,0 +1,242 @@
+package ms
+
+import (
+	"bytes"
+	"hash/crc32"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRecord3_Header(t *testing.T) {
+	raw := RecordHeader3{
+		RecordIndicator:        [2]byte{'M', 'S'},
+		FormatVersion:          3,
+		Flags:                  4,
+		Nanosecond:             123456789,
+		Year:                   2023,
+		DayOfYear:              45,
+		Hour:                   6,
+		Minute:                 7,
+		Second:                 8,
+		Encoding:               11,
+		SampleRate:             100,
+		NumberOfSamples:        412,
+		CRC:                    0xdeadbeef,
+		PublicationVersion:     2,
+		SourceIdentifierLength: 22,
+		ExtraHeadersLength:     31,
+		DataLength:             448,
+	}
+
+	t.Run("encode/decode", func(t *testing.T) {
+		res := DecodeRecordHeader3(EncodeRecordHeader3(raw))
+
+		if raw != res {
+			t.Errorf("encode/decode error, expected %v but got %v", raw, res)
+		}
+	})
+
+	t.Run("start time", func(t *testing.T) {
+		expected := time.Date(2023, 2, 14, 6, 7, 8, 123456789, time.UTC)
+		if s := raw.StartTime(); !s.Equal(expected) {
+			t.Errorf("invalid start time, expected %v but got %v", expected, s)
+		}
+
+		var hdr RecordHeader3
+		hdr.SetStartTime(expected)
+		if s := hdr.StartTime(); !s.Equal(expected) {
+			t.Errorf("invalid start time, expected %v but got %v", expected, s)
+		}
+	})
+
+	t.Run("sample rate", func(t *testing.T) {
+		var hdr RecordHeader3
+		hdr.SetSampleRate(0.1)
+		if hdr.SampleRate != -10 {
+			t.Errorf("invalid sample rate, expected %g but got %g", -10.0, hdr.SampleRate)
+		}
+		if r := hdr.SamplesPerSecond(); r != 0.1 {
+			t.Errorf("invalid samples per second, expected %g but got %g", 0.1, r)
+		}
+	})
+}
+
+func TestRecord3_SourceID(t *testing.T) {
+
+	var sourceIDTests = []struct {
+		sid      string
+		network  string
+		station  string
+		location string
+		channel  string
+	}{
+		{"FDSN:NZ_WEL__H_H_Z", "NZ", "WEL", "", "HHZ"},
+		{"FDSN:NZ_TDHS_20_B_N_1", "NZ", "TDHS", "20", "BN1"},
+		{"FDSN:XX_TEST_00_LOG__", "XX", "TEST", "00", "LOG"},
+	}
+
+	for _, v := range sourceIDTests {
+		t.Run("parse "+v.sid, func(t *testing.T) {
+			network, station, location, channel, err := ParseSourceID(v.sid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if network != v.network || station != v.station || location != v.location || channel != v.channel {
+				t.Errorf("invalid codes, expected %s %s %s %s but got %s %s %s %s",
+					v.network, v.station, v.location, v.channel, network, station, location, channel)
+			}
+		})
+	}
+
+	t.Run("build", func(t *testing.T) {
+		if sid := SourceID("NZ", "WEL", "", "HHZ"); sid != "FDSN:NZ_WEL__H_H_Z" {
+			t.Errorf("invalid source identifier, expected %q but got %q", "FDSN:NZ_WEL__H_H_Z", sid)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, sid := range []string{"NZ_WEL__H_H_Z", "FDSN:NZ_WEL_HHZ"} {
+			if _, _, _, _, err := ParseSourceID(sid); err == nil {
+				t.Errorf("expected an error parsing %q", sid)
+			}
+		}
+	})
+}
+
+func TestRecord3_Checksum(t *testing.T) {
+	// the standard CRC-32C check value
+	if crc := crc32.Checksum([]byte("123456789"), castagnoli); crc != 0xe3069283 {
+		t.Errorf("invalid check value, expected %#08x but got %#08x", 0xe3069283, crc)
+	}
+
+	rec := Record3{
+		SourceIdentifier: "FDSN:XX_TEST_00_H_H_Z",
+		Data:             []byte{1, 0, 0, 0, 2, 0, 0, 0},
+	}
+	rec.Encoding = uint8(EncodingInt32)
+	rec.NumberOfSamples = 2
+	rec.SetStartTime(time.Date(2023, 1, 2, 3, 4, 5, 6, time.UTC))
+	rec.SetSampleRate(100)
+
+	data, err := rec.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := NewRecord3(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples, err := res.Int32s()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0] != 1 || samples[1] != 2 {
+		t.Errorf("invalid samples, expected [1 2] but got %v", samples)
+	}
+
+	data[len(data)-1] ^= 0xff
+	if _, err := NewRecord3(data); err == nil {
+		t.Error("expected a checksum error")
+	}
+}
+
+func TestRecord3_Convert(t *testing.T) {
+
+	files := []string{
+		"4096_float.mseed",
+		"basic.mseed",
+		"empty_location.mseed",
+		"geonet-seedlink-info-ascii.mseed",
+		"NZ.AUCT.40.BTT.mseed",
+		"NZ.CHIT.40.BTT.mseed",
+		"steim1.mseed",
+		"steim1_le.mseed",
+		"steim2_le.mseed",
+		"wel2000.mseed",
+	}
+
+	for _, k := range files {
+		t.Run("convert: "+k, func(t *testing.T) {
+			raw, err := os.ReadFile("testdata/" + k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msr, err := NewRecord(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msr3, err := msr.Record3()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := msr3.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data) != msr3.BlockSize() {
+				t.Errorf("invalid record length, expected %d got %d", msr3.BlockSize(), len(data))
+			}
+
+			res3, err := NewRecord3(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s := res3.SrcName(); s != msr.SrcName(false) {
+				t.Errorf("invalid source name, expected %q got %q", msr.SrcName(false), s)
+			}
+			if !res3.StartTime().Equal(msr.StartTime()) {
+				t.Errorf("invalid start time, expected %v got %v", msr.StartTime(), res3.StartTime())
+			}
+			if res3.SamplesPerSecond() != msr.SampleRate() {
+				t.Errorf("invalid sample rate, expected %g got %g", msr.SampleRate(), res3.SamplesPerSecond())
+			}
+			if q, ok := res3.TimingQuality(); ok != msr.HasBlockette1001() || q != int(msr.B1001.TimingQuality) {
+				t.Errorf("invalid timing quality, expected %d got %d", msr.B1001.TimingQuality, q)
+			}
+
+			back, err := res3.Record()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if back.SrcName(true) != msr.SrcName(true) {
+				t.Errorf("invalid source name, expected %q got %q", msr.SrcName(true), back.SrcName(true))
+			}
+			if !back.StartTime().Equal(msr.StartTime()) {
+				t.Errorf("invalid start time, expected %v got %v", msr.StartTime(), back.StartTime())
+			}
+
+			if msr.Encoding() == EncodingASCII {
+				if !bytes.Equal(res3.Data, msr.Data[:msr.NumberOfSamples]) {
+					t.Errorf("invalid ascii data")
+				}
+				return
+			}
+
+			expected, err := msr.Float64s()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range []interface {
+				Float64s() ([]float64, error)
+			}{res3, back} {
+				samples, err := r.Float64s()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(samples) != len(expected) {
+					t.Fatalf("invalid sample count, expected %d got %d", len(expected), len(samples))
+				}
+				for i := range expected {
+					if samples[i] != expected[i] {
+						t.Errorf("invalid sample %d, expected %g got %g", i, expected[i], samples[i])
+					}
+				}
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package ms
 
 import (
+	"bytes"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestRecord_Unpack(t *testing.T) {
@@ -61,3 +63,52 @@ func TestRecord_File(t *testing.T) {
 		})
 	}
 }
+
+func TestRecord_Marshal(t *testing.T) {
+
+	// records with the blockettes already in the canonical order
+	files := []string{
+		"basic.mseed",
+		"empty_location.mseed",
+		"geonet-seedlink-info-ascii.mseed",
+		"NZ.AUCT.40.BTT.mseed",
+		"NZ.CHIT.40.BTT.mseed",
+		"steim1.mseed",
+		"wel2000.mseed",
+	}
+
+	for _, k := range files {
+		t.Run("unpack/marshal: "+k, func(t *testing.T) {
+			raw, err := os.ReadFile("testdata/" + k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ms, err := NewRecord(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := ms.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(raw[:len(data)], data) || len(data) != ms.BlockSize() {
+				t.Errorf("invalid marshal, record did not round trip")
+			}
+		})
+	}
+}
+
+func TestRecord_SampleOffset(t *testing.T) {
+	rec := NewEmptyRecord(9, 100, 1)
+	rec.SetActualSampleRate(99.99)
+
+	// the period is not a whole number of nanoseconds, so a long offset would drift if it was rounded.
+	rate := float64(float32(99.99))
+	expected := time.Duration(1000000 / rate * float64(time.Second))
+	if d := rec.SampleOffset(1000000) - expected; d > time.Microsecond || d < -time.Microsecond {
+		t.Errorf("invalid sample offset, expected %v but got %v", expected, rec.SampleOffset(1000000))
+	}
+	if d := time.Duration(1000000)*rec.SamplePeriod() - expected; !(d > time.Microsecond || d < -time.Microsecond) {
+		t.Errorf("expected the rounded sample period to drift, got %v", d)
+	}
+}
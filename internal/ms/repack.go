@@ -0,0 +1,198 @@
+package ms
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// segment is a contiguous run of samples from a single stream.
+type segment struct {
+	template Record
+	start    time.Time
+	end      time.Time
+	ints     []int32
+	floats   []float64
+}
+
+// Repacker joins contiguous records of the same stream into a single sample stream which is then
+// packed into new records of the given length and encoding.
+type Repacker struct {
+	reclen    int
+	encoding  Encoding
+	tolerance time.Duration
+
+	counter int
+}
+
+// NewRepacker returns a Repacker that builds records of length 1<<reclen using the given encoding, records
+// are joined if their start time is within the tolerance of the expected time, a zero tolerance will use half
+// of the sample period.
+func NewRepacker(reclen int, encoding Encoding, tolerance time.Duration) (*Repacker, error) {
+	if reclen < MinRecordLength || reclen > MaxRecordLength {
+		return nil, fmt.Errorf("invalid record length: %d", reclen)
+	}
+
+	switch encoding {
+	case EncodingInt32, EncodingIEEEFloat, EncodingIEEEDouble, EncodingSTEIM1, EncodingSTEIM2:
+	default:
+		return nil, fmt.Errorf("unable to repack using encoding %v", encoding)
+	}
+
+	return &Repacker{
+		reclen:    reclen,
+		encoding:  encoding,
+		tolerance: tolerance,
+	}, nil
+}
+
+// Count returns the number of records produced.
+func (r *Repacker) Count() int {
+	return r.counter
+}
+
+// contiguous returns whether the record directly follows the segment.
+func (r *Repacker) contiguous(seg *segment, msr Record) bool {
+	switch {
+	case seg.template.SrcName(true) != msr.SrcName(true):
+		return false
+	case seg.template.SampleRate() != msr.SampleRate():
+		return false
+	}
+
+	limit := r.tolerance
+	if !(limit > 0) {
+		limit = msr.SamplePeriod() / 2
+	}
+
+	d := msr.StartTime().Sub(seg.end.Add(msr.SamplePeriod()))
+
+	return !(d > limit || d < -limit)
+}
+
+// isRepackable returns whether the record holds numerical time series samples.
+func isRepackable(msr Record) bool {
+	switch msr.SampleType() {
+	case IntegerType, FloatType, DoubleType:
+		return msr.SampleRate() > 0
+	default:
+		return false
+	}
+}
+
+// Repack sorts a copy of the records using RecordHeader.Less and joins contiguous records into single sample
+// streams, these are then packed and passed to the callback function. Only gaps or overlaps will start a new
+// record. Records without numerical samples, such as ASCII logs, are passed through unchanged.
+func (r *Repacker) Repack(records []Record, fn RecordFunc) error {
+
+	list := make([]Record, len(records))
+	copy(list, records)
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].RecordHeader.Less(list[j].RecordHeader)
+	})
+
+	var seg *segment
+	for i := range list {
+		msr := list[i]
+
+		if !isRepackable(msr) {
+			if err := r.flush(seg, fn); err != nil {
+				return err
+			}
+			seg = nil
+
+			r.counter++
+			if err := fn(&msr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if seg != nil && !r.contiguous(seg, msr) {
+			if err := r.flush(seg, fn); err != nil {
+				return err
+			}
+			seg = nil
+		}
+
+		if seg == nil {
+			seg = &segment{
+				template: msr,
+				start:    msr.StartTime(),
+			}
+		}
+
+		switch r.encoding {
+		case EncodingIEEEFloat, EncodingIEEEDouble:
+			samples, err := msr.Float64s()
+			if err != nil {
+				return fmt.Errorf("%s: %w", msr.String(), err)
+			}
+			seg.floats = append(seg.floats, samples...)
+		default:
+			samples, err := msr.Int32s()
+			if err != nil {
+				return fmt.Errorf("%s: %w", msr.String(), err)
+			}
+			seg.ints = append(seg.ints, samples...)
+		}
+
+		seg.end = msr.EndTime()
+	}
+
+	return r.flush(seg, fn)
+}
+
+// flush packs the segment samples into new records.
+func (r *Repacker) flush(seg *segment, fn RecordFunc) error {
+	if seg == nil {
+		return nil
+	}
+
+	t := seg.template
+
+	rec := NewEmptyRecord(r.reclen, int(t.SampleRateFactor), int(t.SampleRateMultiplier))
+	rec.SetNetwork(t.Network())
+	rec.SetStation(t.Station())
+	rec.SetLocation(t.Location())
+	rec.SetChannel(t.Channel())
+	rec.DataQualityIndicator = t.DataQualityIndicator
+	rec.ActivityFlags = t.ActivityFlags
+	rec.IOAndClockFlags = t.IOAndClockFlags
+	rec.DataQualityFlags = t.DataQualityFlags
+	rec.B1001.TimingQuality = t.B1001.TimingQuality
+
+	// keep any measured sample rate
+	if blk, ok := t.Blockette(100); ok {
+		rec.AddBlockette(blk)
+	}
+
+	// the segment start time already includes any correction
+	if t.TimeCorrection != 0 {
+		rec.SetCorrection(t.Correction(), true)
+	}
+
+	encode := func(msr *Record) error {
+		r.counter++
+		msr.SetSeqNumber(r.counter)
+		return fn(msr)
+	}
+
+	switch r.encoding {
+	case EncodingIEEEFloat:
+		values := make([]float32, 0, len(seg.floats))
+		for _, v := range seg.floats {
+			values = append(values, float32(v))
+		}
+		return rec.PackFloat32(seg.start, values, encode)
+	case EncodingIEEEDouble:
+		return rec.PackFloat64(seg.start, seg.floats, encode)
+	case EncodingSTEIM1:
+		return rec.PackSteim1(seg.start, 0, seg.ints, encode)
+	case EncodingSTEIM2:
+		return rec.PackSteim2(seg.start, 0, seg.ints, encode)
+	default:
+		return rec.PackInt32(seg.start, seg.ints, encode)
+	}
+}
@@ -0,0 +1,84 @@
+package ms
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRepack_Repack(t *testing.T) {
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	samples := make([]int32, 2000)
+	for i := range samples {
+		samples[i] = int32((i * 37) % 1001)
+	}
+
+	// build fragmented records, each holding 50 samples, with a gap after 1500 samples
+	var records []Record
+	for i := 0; i < len(samples); i += 50 {
+		rec := NewEmptyRecord(9, 100, 1)
+		rec.SetNetwork("NZ")
+		rec.SetStation("WEL")
+		rec.SetChannel("HHZ")
+
+		at := start.Add(time.Duration(i) * 10 * time.Millisecond)
+		if i >= 1500 {
+			at = at.Add(time.Second)
+		}
+		if err := rec.PackInt32(at, samples[i:i+50], func(r *Record) error {
+			records = append(records, *r)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, enc := range []Encoding{EncodingInt32, EncodingSTEIM1, EncodingSTEIM2, EncodingIEEEFloat, EncodingIEEEDouble} {
+		t.Run(fmt.Sprintf("encoding %d", enc), func(t *testing.T) {
+			repacker, err := NewRepacker(12, enc, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var packed []Record
+			if err := repacker.Repack(records, func(r *Record) error {
+				packed = append(packed, *r)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if !(len(packed) < len(records)) {
+				t.Errorf("invalid repack, expected fewer than %d records got %d", len(records), len(packed))
+			}
+			if gaps := FindGaps(packed, 0); len(gaps) != 1 || gaps[0].Duration() != time.Second {
+				t.Errorf("invalid gaps, expected a single gap of 1s but got %v", gaps)
+			}
+
+			var values []float64
+			for _, r := range packed {
+				if r.BlockSize() != 4096 {
+					t.Errorf("invalid block size, expected %d got %d", 4096, r.BlockSize())
+				}
+				if Encoding(r.B1000.Encoding) != enc {
+					t.Errorf("invalid encoding, expected %d got %d", enc, r.B1000.Encoding)
+				}
+				v, err := r.Float64s()
+				if err != nil {
+					t.Fatal(err)
+				}
+				values = append(values, v...)
+			}
+
+			if len(values) != len(samples) {
+				t.Fatalf("invalid sample count, expected %d got %d", len(samples), len(values))
+			}
+			for i := range samples {
+				if values[i] != float64(samples[i]) {
+					t.Errorf("invalid sample %d, expected %d got %g", i, samples[i], values[i])
+				}
+			}
+		})
+	}
+}
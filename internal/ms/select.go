@@ -0,0 +1,171 @@
+package ms
+
+import (
+	"math"
+	"path"
+	"strings"
+	"time"
+)
+
+// Selection describes the records of interest. The stream codes are glob patterns, as used by path.Match, with
+// an empty pattern matching any code. Quality holds the accepted quality indicators, e.g. "DR", with an empty
+// value accepting any indicator. The time window includes the Start time but not the End time, a zero time
+// leaves that side of the window open.
+type Selection struct {
+	Network  string
+	Station  string
+	Location string
+	Channel  string
+	Quality  string
+
+	Start time.Time
+	End   time.Time
+}
+
+// match checks a code against a glob pattern, an invalid pattern will not match.
+func match(pattern, code string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, err := path.Match(pattern, code)
+	return ok && err == nil
+}
+
+// Match returns whether the record stream codes and quality match, and whether the record overlaps the time window.
+func (s Selection) Match(msr Record) bool {
+	switch {
+	case !match(s.Network, msr.Network()):
+		return false
+	case !match(s.Station, msr.Station()):
+		return false
+	case !match(s.Location, msr.Location()):
+		return false
+	case !match(s.Channel, msr.Channel()):
+		return false
+	case s.Quality != "" && !strings.ContainsRune(s.Quality, rune(msr.DataQualityIndicator)):
+		return false
+	case !s.End.IsZero() && !msr.StartTime().Before(s.End):
+		return false
+	case !s.Start.IsZero() && msr.EndTime().Before(s.Start):
+		return false
+	default:
+		return true
+	}
+}
+
+// Window returns the index of the first sample in the record that is within the time window, and the
+// index after the last one. Records without a sample rate are either fully included or excluded.
+func (s Selection) Window(msr Record) (int, int) {
+	n := msr.SampleCount()
+
+	if !(msr.ActualSampleRate() > 0) {
+		if !s.Match(msr) {
+			return 0, 0
+		}
+		return 0, n
+	}
+
+	first, last := 0, n
+
+	start := msr.StartTime()
+	if !s.Start.IsZero() && s.Start.After(start) {
+		first = sampleIndex(msr, start, s.Start)
+	}
+	if !s.End.IsZero() {
+		last = sampleIndex(msr, start, s.End)
+	}
+
+	switch {
+	case first > n:
+		first = n
+	case first < 0:
+		first = 0
+	}
+	switch {
+	case last > n:
+		last = n
+	case last < first:
+		last = first
+	}
+
+	return first, last
+}
+
+// sampleIndex returns the index of the first sample at or after the given time, the sample times are found
+// using the actual sample rate in the same way as the record end time.
+func sampleIndex(msr Record, start, at time.Time) int {
+	n := int(math.Ceil(at.Sub(start).Seconds() * msr.ActualSampleRate()))
+	for n > 0 && !start.Add(msr.SampleOffset(n-1)).Before(at) {
+		n--
+	}
+	for start.Add(msr.SampleOffset(n)).Before(at) {
+		n++
+	}
+	return n
+}
+
+// Trim passes the record to the callback function if it is fully within the time window, otherwise the samples
+// inside the window are repacked into new records using the same record length and encoding. Records that do not
+// hold numerical samples cannot be trimmed and are passed on unchanged.
+func (s Selection) Trim(msr *Record, fn RecordFunc) error {
+	first, last := s.Window(*msr)
+
+	switch n := msr.SampleCount(); {
+	case first >= last:
+		return nil
+	case first == 0 && last == n:
+		return fn(msr)
+	case !isRepackable(*msr):
+		return fn(msr)
+	}
+
+	rec := &Record{
+		RecordHeader: msr.RecordHeader,
+		B1000:        msr.B1000,
+		B1001:        msr.B1001,
+		Blockettes:   msr.Blockettes,
+		layout:       msr.layout,
+	}
+
+	// the new start time will already include any correction
+	if rec.TimeCorrection != 0 {
+		rec.SetCorrection(rec.Correction(), true)
+	}
+
+	start := msr.StartTime().Add(msr.SampleOffset(first))
+
+	switch msr.Encoding() {
+	case EncodingIEEEFloat, EncodingIEEEDouble:
+		samples, err := msr.Float64s()
+		if err != nil {
+			return err
+		}
+		if msr.Encoding() == EncodingIEEEDouble {
+			return rec.PackFloat64(start, samples[first:last], fn)
+		}
+		values := make([]float32, 0, last-first)
+		for _, v := range samples[first:last] {
+			values = append(values, float32(v))
+		}
+		return rec.PackFloat32(start, values, fn)
+	default:
+		samples, err := msr.Int32s()
+		if err != nil {
+			return err
+		}
+
+		var prev int32
+		if first > 0 {
+			prev = samples[first-1]
+		}
+
+		switch msr.Encoding() {
+		case EncodingSTEIM1:
+			return rec.PackSteim1(start, prev, samples[first:last], fn)
+		case EncodingSTEIM2:
+			return rec.PackSteim2(start, prev, samples[first:last], fn)
+		default:
+			return rec.PackInt32(start, samples[first:last], fn)
+		}
+	}
+}
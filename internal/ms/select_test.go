@@ -0,0 +1,222 @@
+package ms
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSelection_Match(t *testing.T) {
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	rec := NewEmptyRecord(9, 100, 1)
+	rec.SetNetwork("NZ")
+	rec.SetStation("WEL")
+	rec.SetLocation("10")
+	rec.SetChannel("HHZ")
+
+	var msr Record
+	if err := rec.PackInt32(start, make([]int32, 100), func(r *Record) error {
+		msr = *r
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		sel Selection
+		ok  bool
+	}{
+		"empty":      {Selection{}, true},
+		"codes":      {Selection{Network: "NZ", Station: "WEL", Location: "10", Channel: "HHZ"}, true},
+		"glob":       {Selection{Station: "W*", Channel: "HH?"}, true},
+		"station":    {Selection{Station: "TDHS"}, false},
+		"channel":    {Selection{Channel: "HN?"}, false},
+		"invalid":    {Selection{Channel: "[HH"}, false},
+		"quality":    {Selection{Quality: "DR"}, true},
+		"quality2":   {Selection{Quality: "Q"}, false},
+		"window":     {Selection{Start: start.Add(-time.Second), End: start.Add(time.Second)}, true},
+		"inside":     {Selection{Start: start.Add(100 * time.Millisecond), End: start.Add(200 * time.Millisecond)}, true},
+		"before":     {Selection{End: start}, false},
+		"after":      {Selection{Start: start.Add(time.Second)}, false},
+		"last":       {Selection{Start: start.Add(990 * time.Millisecond)}, true},
+		"open start": {Selection{End: start.Add(10 * time.Millisecond)}, true},
+	}
+
+	for k, v := range tests {
+		t.Run(k, func(t *testing.T) {
+			if ok := v.sel.Match(msr); ok != v.ok {
+				t.Errorf("invalid match, expected %v got %v", v.ok, ok)
+			}
+		})
+	}
+}
+
+func TestSelection_Trim(t *testing.T) {
+	samples := steimTestSamples()
+
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for _, enc := range []Encoding{EncodingInt32, EncodingSTEIM1, EncodingSTEIM2, EncodingIEEEDouble} {
+		t.Run(fmt.Sprintf("encoding %d", enc), func(t *testing.T) {
+			rec := newSteimTestRecord()
+
+			var records []*Record
+			keep := func(r *Record) error {
+				records = append(records, r)
+				return nil
+			}
+
+			var err error
+			switch enc {
+			case EncodingSTEIM1:
+				err = rec.PackSteim1(rec.StartTime(), 0, samples, keep)
+			case EncodingSTEIM2:
+				err = rec.PackSteim2(rec.StartTime(), 0, samples, keep)
+			case EncodingIEEEDouble:
+				values := make([]float64, 0, len(samples))
+				for _, v := range samples {
+					values = append(values, float64(v))
+				}
+				err = rec.PackFloat64(rec.StartTime(), values, keep)
+			default:
+				err = rec.PackInt32(rec.StartTime(), samples, keep)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sel := Selection{
+				Start: start.Add(150 * time.Millisecond),
+				End:   start.Add(time.Duration(len(samples)-20) * 10 * time.Millisecond),
+			}
+
+			var trimmed []int32
+			var first time.Time
+			for _, msr := range records {
+				if err := sel.Trim(msr, func(r *Record) error {
+					if first.IsZero() {
+						first = r.StartTime()
+					}
+					if r.BlockSize() != msr.BlockSize() {
+						t.Errorf("invalid record length, expected %d got %d", msr.BlockSize(), r.BlockSize())
+					}
+					if r.Encoding() != enc {
+						t.Errorf("invalid encoding, expected %v got %v", enc, r.Encoding())
+					}
+					values, err := r.Int32s()
+					if err != nil {
+						return err
+					}
+					trimmed = append(trimmed, values...)
+					return nil
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !first.Equal(sel.Start) {
+				t.Errorf("invalid start time, expected %v got %v", sel.Start, first)
+			}
+
+			expected := samples[15 : len(samples)-20]
+			if len(trimmed) != len(expected) {
+				t.Fatalf("invalid sample count, expected %d got %d", len(expected), len(trimmed))
+			}
+			for i := range expected {
+				if trimmed[i] != expected[i] {
+					t.Fatalf("invalid sample %d, expected %d got %d", i, expected[i], trimmed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSelection_ActualRate(t *testing.T) {
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	rec := NewEmptyRecord(12, 100, 1)
+	rec.SetStation("WEL")
+	rec.SetChannel("HHZ")
+	rec.SetActualSampleRate(100.0001)
+
+	var msr *Record
+	if err := rec.PackInt32(start, make([]int32, 900), func(r *Record) error {
+		msr = r
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if msr == nil || msr.SampleCount() != 900 {
+		t.Fatal("expected a single record")
+	}
+
+	// the window edges fall on samples spaced using the measured rate, a rounded sample period would be off by one.
+	sel := Selection{
+		Start: start.Add(msr.SampleOffset(400)),
+		End:   start.Add(msr.SampleOffset(800)),
+	}
+
+	if first, last := sel.Window(*msr); first != 400 || last != 800 {
+		t.Errorf("invalid window, expected %d to %d but got %d to %d", 400, 800, first, last)
+	}
+
+	var count int
+	if err := sel.Trim(msr, func(r *Record) error {
+		// record start times are only held to the nearest microsecond.
+		if d := r.StartTime().Sub(sel.Start); count == 0 && (d < -time.Microsecond || d > time.Microsecond) {
+			t.Errorf("invalid start time, expected %v got %v", sel.Start, r.StartTime())
+		}
+		count += r.SampleCount()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 400 {
+		t.Errorf("invalid sample count, expected %d got %d", 400, count)
+	}
+}
+
+func TestSelection_ProcessSelection(t *testing.T) {
+	var samples []int32
+	for i := 0; i < 4; i++ {
+		samples = append(samples, steimTestSamples()...)
+	}
+
+	rec := newSteimTestRecord()
+	start := rec.StartTime()
+
+	var buf bytes.Buffer
+	if err := rec.PackSteim2(start, 0, samples, func(r *Record) error {
+		raw, err := r.Marshal()
+		if err != nil {
+			return err
+		}
+		buf.Write(raw)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	sel := Selection{
+		Channel: "HH?",
+		Start:   start.Add(time.Second),
+		End:     start.Add(2 * time.Second),
+	}
+
+	var count int
+	if err := ProcessSelection(&buf, 0, sel, func(src string, at time.Time, delta time.Duration, values ...float64) error {
+		if at.Before(sel.Start) || !at.Before(sel.End) {
+			t.Errorf("invalid start time %v", at)
+		}
+		count += len(values)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 100 {
+		t.Errorf("invalid sample count, expected %d got %d", 100, count)
+	}
+}
@@ -61,11 +61,42 @@ func applyDifferencesFromWord(w []byte, numdiffs int, diffbits uint32, d []int32
 	return d
 }
 
+// swapSteim returns a copy of the steim frames with the word order reversed, the little flag indicates
+// whether the given frames are little endian. Following libmseed, words holding 8 bit differences are left
+// alone in both versions, steim1 16 bit differences are swapped individually, and all other words are
+// swapped as whole 32 bit values.
+func swapSteim(version int, raw []byte, little bool) []byte {
+	res := make([]byte, len(raw))
+	copy(res, raw)
+
+	for f := 0; f+64 <= len(res); f += 64 {
+		frame := res[f : f+64]
+
+		ctrl := binary.BigEndian.Uint32(frame[0:4])
+		if little {
+			ctrl = binary.LittleEndian.Uint32(frame[0:4])
+		}
+
+		for w := 0; w < 16; w++ {
+			word := frame[w*4 : (w+1)*4]
+			switch nib := (ctrl >> uint(30-2*w)) & 0x3; {
+			case nib == 1:
+			case version == 1 && nib == 2:
+				word[0], word[1], word[2], word[3] = word[1], word[0], word[3], word[2]
+			default:
+				word[0], word[1], word[2], word[3] = word[3], word[2], word[1], word[0]
+			}
+		}
+	}
+
+	return res
+}
+
 func decodeSteim(version int, raw []byte, wordOrder, frameCount uint8, expectedSamples uint16) ([]int32, error) {
 	d := make([]int32, 0, expectedSamples)
 
-	if wordOrder == 0 {
-		return d, fmt.Errorf("steim%v: no support for little endian", version)
+	if WordOrder(wordOrder) == LittleEndian {
+		raw = swapSteim(version, raw, true)
 	}
 
 	//Word 1 and 2 contain x0 and xn: the uncompressed initial and final quantities (word 0 contains nibs)
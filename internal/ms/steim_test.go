@@ -2,7 +2,9 @@ package ms
 
 import (
 	"bytes"
+	"os"
 	"testing"
+	"time"
 )
 
 func TestSteim_Nibble(t *testing.T) {
@@ -75,3 +77,148 @@ func TestSteim_Int(t *testing.T) {
 		})
 	}
 }
+
+// steimTestSamples returns values that need the full range of steim difference widths.
+func steimTestSamples() []int32 {
+	var samples []int32
+	for i := 0; i < 100; i++ {
+		var v int32
+		switch i % 4 {
+		case 0:
+			v = int32(i)
+		case 1:
+			v = int32(i * 1000)
+		case 2:
+			v = int32(-i * 100000)
+		default:
+			v = int32(i * i)
+		}
+		samples = append(samples, v)
+	}
+	// small differences are packed as 8 bit values.
+	for i := 0; i < 40; i++ {
+		samples = append(samples, int32(i%2)*100)
+	}
+	return samples
+}
+
+// newSteimTestRecord returns the template used to build the packed steim fixtures, the little endian
+// fixtures were converted from big endian records by testdata/steim_le.py rather than by this package.
+func newSteimTestRecord() *Record {
+	rec := NewEmptyRecord(9, 100, 1)
+	rec.SetNetwork("XX")
+	rec.SetStation("TEST")
+	rec.SetLocation("00")
+	rec.SetChannel("HHZ")
+	rec.RecordStartTime = NewBTime(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC))
+	return rec
+}
+
+func TestSteim_LittleEndian(t *testing.T) {
+
+	t.Run("decode", func(t *testing.T) {
+		files := map[string]string{
+			"steim1_le.mseed": "steim1.mseed",
+			"steim2_le.mseed": "basic.mseed",
+		}
+
+		for k, v := range files {
+			little, err := os.ReadFile("testdata/" + k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			big, err := os.ReadFile("testdata/" + v)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lrec, err := NewRecord(little)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !lrec.IsLittleEndian() {
+				t.Fatalf("%s: expected a little endian record", k)
+			}
+			brec, err := NewRecord(big)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lsamples, err := lrec.Int32s()
+			if err != nil {
+				t.Fatalf("%s: %v", k, err)
+			}
+			bsamples, err := brec.Int32s()
+			if err != nil {
+				t.Fatalf("%s: %v", v, err)
+			}
+
+			if len(lsamples) != len(bsamples) {
+				t.Fatalf("%s: invalid sample count, expected %d got %d", k, len(bsamples), len(lsamples))
+			}
+			for i := range bsamples {
+				if lsamples[i] != bsamples[i] {
+					t.Errorf("%s: invalid sample %d, expected %d got %d", k, i, bsamples[i], lsamples[i])
+				}
+			}
+		}
+	})
+
+	packers := map[string]func(*Record, []int32, RecordFunc) error{
+		"steim1_le_packed.mseed": func(r *Record, s []int32, fn RecordFunc) error {
+			return r.PackSteim1(r.StartTime(), 0, s, fn)
+		},
+		"steim2_le_packed.mseed": func(r *Record, s []int32, fn RecordFunc) error {
+			return r.PackSteim2(r.StartTime(), 0, s, fn)
+		},
+	}
+
+	for k, pack := range packers {
+		t.Run("pack "+k, func(t *testing.T) {
+			raw, err := os.ReadFile("testdata/" + k)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := newSteimTestRecord()
+			rec.SetLittleEndian()
+
+			var data []byte
+			if err := pack(rec, steimTestSamples(), func(r *Record) error {
+				if data != nil {
+					return nil
+				}
+				if !r.IsLittleEndian() {
+					t.Errorf("expected a little endian record")
+				}
+				b, err := r.Marshal()
+				if err != nil {
+					return err
+				}
+				data = b
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(raw, data) {
+				t.Errorf("packed record does not match fixture")
+			}
+
+			res, err := NewRecord(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			samples, err := res.Int32s()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := steimTestSamples()[:len(samples)]
+			for i := range expected {
+				if samples[i] != expected[i] {
+					t.Errorf("invalid sample %d, expected %d got %d", i, expected[i], samples[i])
+				}
+			}
+		})
+	}
+}
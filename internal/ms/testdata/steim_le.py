@@ -0,0 +1,64 @@
+#!/usr/bin/env python3
+"""Convert big endian steim miniSEED records into little endian data records.
+
+The word swapping follows the libmseed rules and is written independently of
+the go code so that the little endian fixtures can be used to check it:
+
+  * control words and 32 bit words are byte reversed,
+  * steim1 words holding two 16 bit differences have each half swapped,
+  * words holding four 8 bit differences are never swapped.
+
+Only the data frames and the blockette 1000 word order flag are changed, the
+fixed header and blockettes are left big endian.
+
+Usage: steim_le.py <input> <output>
+"""
+
+import struct
+import sys
+
+
+def blockettes(rec):
+    offset = struct.unpack(">H", rec[46:48])[0]
+    while offset:
+        kind, offset_next = struct.unpack(">HH", rec[offset:offset + 4])
+        yield kind, offset
+        offset = offset_next
+
+
+def convert(rec):
+    rec = bytearray(rec)
+    b1000 = dict(blockettes(rec))[1000]
+    encoding, order, exponent = rec[b1000 + 4], rec[b1000 + 5], rec[b1000 + 6]
+    if encoding not in (10, 11) or order != 1:
+        raise ValueError("expected a big endian steim record")
+    version = encoding - 9
+    rec[b1000 + 5] = 0
+
+    start = struct.unpack(">H", rec[44:46])[0]
+    for frame in range(start, 1 << exponent, 64):
+        ctrl = struct.unpack(">I", rec[frame:frame + 4])[0]
+        for w in range(16):
+            nib = (ctrl >> (30 - 2 * w)) & 0x3
+            word = rec[frame + 4 * w:frame + 4 * w + 4]
+            if w > 0 and nib == 1:
+                continue
+            if w > 0 and version == 1 and nib == 2:
+                word = word[1::-1] + word[:1:-1]
+            else:
+                word = word[::-1]
+            rec[frame + 4 * w:frame + 4 * w + 4] = word
+    return bytes(rec)
+
+
+def main():
+    with open(sys.argv[1], "rb") as f:
+        data = f.read()
+    size = 1 << data[dict(blockettes(data))[1000] + 6]
+    with open(sys.argv[2], "wb") as f:
+        for i in range(0, len(data), size):
+            f.write(convert(data[i:i + size]))
+
+
+if __name__ == "__main__":
+    main()
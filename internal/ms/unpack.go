@@ -18,6 +18,8 @@ func (m *Record) Unpack(buf []byte) error {
 		return fmt.Errorf("unpack: input is not a valid MSEED record: incorrect header")
 	}
 
+	m.Blockettes, m.layout = nil, []uint16{}
+
 	pointer := m.RecordHeader.FirstBlockette //TODO: This could be replaced with bytes.Reader()
 	for i := 0; i < int(m.RecordHeader.NumberOfBlockettesThatFollow); i++ {
 		if pointer == 0 {
@@ -42,7 +44,35 @@ func (m *Record) Unpack(buf []byte) error {
 				return fmt.Errorf("unpack: given %v bytes; not enough to parse blockette 1001 at %v", len(buf), bpointer)
 			}
 			m.B1001 = DecodeBlockette1001(buf[bpointer : bpointer+Blockette1001Size])
+		case 100, 200, 201, 300, 310, 320, 390, 395, 400, 500, 2000:
+			blk, ok := DecodeBlockette(bhead.BlocketteType, buf[bpointer:])
+			if !ok {
+				return fmt.Errorf("unpack: given %v bytes; not enough to parse blockette %v at %v", len(buf), bhead.BlocketteType, bpointer)
+			}
+			m.Blockettes = append(m.Blockettes, blk)
+		case 405:
+			// the number of beam delays is only given by the blockette length, if it is the last
+			// blockette any padding before the data is read as zero delays.
+			end, ok := m.contentEnd(bhead, bpointer, len(buf))
+			if !ok {
+				return fmt.Errorf("unpack: unable to find the length of blockette %v at %v", bhead.BlocketteType, bpointer)
+			}
+			blk, ok := DecodeBlockette(bhead.BlocketteType, buf[bpointer:end])
+			if !ok {
+				return fmt.Errorf("unpack: given %v bytes; not enough to parse blockette %v at %v", len(buf), bhead.BlocketteType, bpointer)
+			}
+			m.Blockettes = append(m.Blockettes, blk)
+		default:
+			end, ok := m.contentEnd(bhead, bpointer, len(buf))
+			if !ok {
+				return fmt.Errorf("unpack: unable to find the length of blockette %v at %v", bhead.BlocketteType, bpointer)
+			}
+			m.Blockettes = append(m.Blockettes, BlocketteUnknown{
+				Type:    bhead.BlocketteType,
+				Content: append([]byte(nil), buf[bpointer:end]...),
+			})
 		}
+		m.layout = append(m.layout, bhead.BlocketteType)
 
 		pointer = bhead.NextBlockette
 	}
@@ -53,6 +83,19 @@ func (m *Record) Unpack(buf []byte) error {
 	return nil
 }
 
+// contentEnd returns the end of a blockette's content, which runs up to the next blockette,
+// or to the data if this is the last one.
+func (m *Record) contentEnd(bhead BlocketteHeader, bpointer uint16, size int) (int, bool) {
+	end := int(bhead.NextBlockette)
+	if end <= int(bpointer) {
+		end = int(m.RecordHeader.BeginningOfData)
+	}
+	if end <= int(bpointer) || end > size {
+		return 0, false
+	}
+	return end, true
+}
+
 // Bytes returns the record as a bytes slice for ASCII encoded records.
 func (m Record) Bytes() ([]byte, error) {
 	switch enc := Encoding(m.B1000.Encoding); enc {